@@ -1,15 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"encoding/csv"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/gexf"
 	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/html"
 	"github.com/rhagenson/relped/internal/io/demographics"
 	"github.com/rhagenson/relped/internal/io/parentage"
 	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/linkage"
 	"github.com/rhagenson/relped/internal/pedigree"
 	"github.com/rhagenson/relped/internal/unit/relational"
 	"github.com/rhagenson/relped/internal/util"
@@ -18,7 +29,29 @@ import (
 	"github.com/spf13/pflag"
 )
 
-var minDist = relational.Ninth
+var (
+	minDist      = relational.Ninth
+	weightScheme = graph.EqualScheme
+	csvOpts      util.CsvOptions
+	rounding     = util.RoundNearest
+	precision    util.Precision
+)
+
+// mlRelateMaxDistance is the furthest relationship ML-Relate reports
+const mlRelateMaxDistance = uint(relational.Third)
+
+// presets are the --max-distance set by each --preset
+var presets = map[string]uint{
+	"close":    uint(relational.First),
+	"family":   uint(relational.Third),
+	"extended": uint(relational.Fifth),
+}
+
+// presetNames are the keys of presets from closest to furthest
+var presetNames = []string{"close", "family", "extended"}
+
+// pruneStrategies are the ways of pruning, the first being the default
+var pruneStrategies = []string{"shortest", "betweenness"}
 
 // Required flags
 var (
@@ -31,13 +64,101 @@ var (
 	fDemographics string
 	fParentage    string
 	fUnmapped     string
+	fImputed      string
+	fInbreeding   string
+	fAmbiguous    string
+	fUnknowns     string
+	fDetours      string
+	fIndividuals  string
+	fDropped      string
+	fTrace        string
+	fSummary      string
+	fRender       string
+	fLayout       string
+	fCacheDir     string
 )
 
 // General use flags
 var (
-	opNormalize      bool
-	opMinRelatedness string
-	opRmArrows       bool
+	opNormalize        bool
+	opNormalizeData    bool
+	opStrict           bool
+	opCollectErrors    bool
+	opPercent          bool
+	opIntervals        bool
+	opCategorical      bool
+	opNoTrimIDs        bool
+	opHeader           bool
+	opNoHeader         bool
+	opMLRelate         bool
+	opInputFormat      string
+	opRelationshipsCol string
+	opMLProbabilities  bool
+	opMLColumns        relatedness.ColumnNumbers
+	opMinRelatedness   string
+	opRounding         string
+	opMaxDistance      uint
+	opRmArrows         bool
+	opSimpleLayout     bool
+	opWeightScheme     string
+	opIDDelimiter      string
+	opSplitBy          string
+	opBridgeBy         string
+	opClusterBy        string
+	opTimeout          time.Duration
+	opPruneStrategy    string
+	opStableIDs        bool
+	opExplain          []string
+	opUnknownPrefix    string
+	opValidate         bool
+	opLayoutEngine     string
+	opRankBy           string
+	opFormat           string
+	opThreads          int
+	opPruneIsolated    bool
+	opKeepDisconnected bool
+	opMaxEdges         int
+	opTrimEdges        bool
+	opMkdir            bool
+	opMinComponentSize int
+	opMarkersCol       string
+	opCarryCol         string
+	opRelCols          []string
+	opPreset           string
+	opFlagDuplicates   bool
+	opMergeDuplicates  bool
+	opDupThreshold     float64
+	opPrecision        int
+	opCategoryTopology bool
+	opShareUnknowns    bool
+	opSelfInbreeding   bool
+	opInferDirection   string
+	opCompact          bool
+	opColorBy          string
+	opLegend           bool
+	opEdgeColor        bool
+	opEdgeWidth        bool
+	opTooltips         bool
+	opGenerationGap    uint
+	opRelLabels        bool
+	fRelLabels         string
+	opDistanceWeights  bool
+	opRelPower         float64
+	opRelSquared       bool
+	opNoMergeRecip     bool
+	opAggregate        string
+	opMaxUnknownChain  int
+	opSeed             int64
+	opAssumeSymmetric  bool
+	opFormats          []string
+	opResume           bool
+)
+
+// CSV parsing flags
+var (
+	opLazyQuotes       bool
+	opCommentChar      string
+	opTrimLeadingSpace bool
 )
 
 // buildCmd represents the build command
@@ -48,7 +169,7 @@ var buildCmd = &cobra.Command{
 demographics and parentage information to build an effective pedigree, 
 generating the necessary number of unknown individuals.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		build()
+		build(cmd.Flags())
 	},
 }
 
@@ -60,48 +181,289 @@ func init() {
 	buildCmd.MarkFlagRequired("relatedness")
 	buildCmd.Flags().StringVar(&fOut, "output", "", "Output DOT file (required)")
 	buildCmd.MarkFlagRequired("output")
+	buildCmd.Flags().IntVar(&opPrecision, "precision", -1, "Decimal places in numeric output, -1 for as many as needed")
+	buildCmd.Flags().StringSliceVar(&opFormats, "formats", nil, "Write several formats of --output at once, replacing its extension with each (e.g. --formats dot,gexf)")
+	buildCmd.Flags().StringVar(&opFormat, "format", "dot", "Format of --output: dot, gexf, html for an interactive page, matrix for a CSV of distances between individuals, ped for a LINKAGE pedigree, kinship2 for a CSV of parents to read in R, or induced for a DOT graph of individuals alone linked by their distance in the pedigree")
 
 	// Optional inputs
 	buildCmd.Flags().StringVar(&fDemographics, "demographics", "", "Three-column demographics file")
 	buildCmd.Flags().StringVar(&fParentage, "parentage", "", "Three-column parentage file")
 	buildCmd.Flags().StringVar(&fUnmapped, "unmapped", "", "File of unmapped individuals from relatedness")
+	buildCmd.Flags().StringVar(&fImputed, "impute-missing", "", "File of relatedness imputed for pairs missing from relatedness")
+	buildCmd.Flags().StringVar(&fRender, "also-render", "", "Image file to also render the pedigree to with Graphviz (e.g. pedigree.svg)")
+	buildCmd.Flags().StringVar(&fLayout, "save-layout", "", "GraphML file to save the position Graphviz gives each individual to")
+	buildCmd.Flags().StringVar(&fSummary, "output-summary", "", "JSON file summarizing the inputs, options, and resulting pedigree")
+	buildCmd.Flags().StringVar(&fUnknowns, "report-unknowns", "", "File of inferred unknowns and the individuals each links, as candidates for sampling")
+	buildCmd.Flags().StringVar(&fDetours, "report-detours", "", "File of pairs drawn at a different distance than their relatedness implies")
+	buildCmd.Flags().StringVar(&fTrace, "trace", "", "File of each link with the relatedness, relational distance, and weight of every relationship drawn through it")
+	buildCmd.Flags().StringVar(&fAmbiguous, "report-ambiguous", "", "File of known pairs linked by more than one equally short path")
+	buildCmd.Flags().StringVar(&fIndividuals, "output-individuals", "", "File of known individuals in the pedigree, one per line")
+	buildCmd.Flags().StringVar(&fDropped, "output-dropped", "", "File of individuals in relatedness left out of the pedigree and why")
+	buildCmd.Flags().StringVar(&fInbreeding, "inbreeding", "", "File of inbreeding coefficients estimated from the pedigree")
 
 	// Behavioral changes
+	buildCmd.Flags().BoolVar(&opStrict, "strict", false, "Fail on questionable input rather than warn")
+	buildCmd.Flags().BoolVar(&opCollectErrors, "collect-errors", false, "Report every failing line of --relatedness before stopping, rather than only the first")
+	buildCmd.Flags().BoolVar(&opValidate, "validate", false, "Report individuals linked to more than two unknown parents, failing under --strict")
+	buildCmd.Flags().BoolVar(&opMLRelate, "ml-relate", false, "Read --relatedness as ML-Relate output")
+	buildCmd.Flags().StringVar(&opInputFormat, "input-format", relatedness.ThreeColumnFormat, "Format of --relatedness, or auto to detect it from the first row: "+strings.Join(relatedness.Formats, ", "))
+	buildCmd.Flags().BoolVar(&opMLProbabilities, "ml-relate-probabilities", false, "Weight ML-Relate relationships by their probability rather than Relatedness")
+	buildCmd.Flags().StringVar(&opRelationshipsCol, "relationships-column", "", "Pick the closest or furthest of ML-Relate's plausible Relationships rather than the most likely")
+	buildCmd.Flags().UintVar(&opMLColumns.Ind1, "ml-col-ind1", 0, "Number of the ML-Relate column, counted from 1, with the first ID of each pair, in place of Ind1")
+	buildCmd.Flags().UintVar(&opMLColumns.Ind2, "ml-col-ind2", 0, "Number of the ML-Relate column, counted from 1, with the second ID of each pair, in place of Ind2")
+	buildCmd.Flags().UintVar(&opMLColumns.Category, "ml-col-category", 0, "Number of the ML-Relate column, counted from 1, with the most likely relationship, in place of R")
+	buildCmd.Flags().UintVar(&opMLColumns.Relatedness, "ml-col-relatedness", 0, "Number of the ML-Relate column, counted from 1, with the estimated relatedness, in place of Relatedness")
+	buildCmd.Flags().BoolVar(&opPercent, "percent", false, "Read relatedness as percentages (e.g. 50 for 0.5)")
+	buildCmd.Flags().BoolVar(&opCategorical, "input-categorical", false, "Read relatedness as relationships: PO, FS, HS, or U")
+	buildCmd.Flags().BoolVar(&opIntervals, "intervals", false, "Read relatedness with confidence intervals, e.g. 0.45[0.30,0.60] or 0.45 (0.30-0.60)")
 	buildCmd.Flags().BoolVar(&opNormalize, "normalize", false, "Normalize relatedness to [0,1]-bounded")
+	buildCmd.Flags().BoolVar(&opNormalizeData, "normalize-to-data", false, "Normalize relatedness so the observed minimum is 0 and maximum is 1")
 	buildCmd.Flags().StringVar(&opMinRelatedness, "min-relatedness", "U", "Minimum relational distance to incorporate")
+	buildCmd.Flags().StringVar(&opRounding, "rounding", "nearest", "Rounding of relatedness between two relational distances: nearest, floor for the closer, or ceil for the further")
+	buildCmd.Flags().StringVar(&opPreset, "preset", "", "Include relationships by intent, overridden by --max-distance: "+strings.Join(presetNames, ", "))
+	buildCmd.Flags().UintVar(&opMaxDistance, "max-distance", uint(relational.Ninth), "Maximum relational distance to incorporate")
+	buildCmd.Flags().BoolVar(&opSelfInbreeding, "keep-self-loops-as-inbreeding", false, "Mark individuals related to themselves in relatedness with a double border")
 	buildCmd.Flags().BoolVar(&opRmArrows, "rm-arrows", false, "Remove arrows heads from pedigree, instead use simple lines")
+	buildCmd.Flags().StringVar(&opInferDirection, "infer-direction-from", "", "Direct relationships between individuals from higher to lower values of this information (e.g. age), leaving others without arrows: "+strings.Join(graph.InfoFields, ", "))
+	buildCmd.Flags().StringVar(&opColorBy, "color-by", "", "Fill individuals sharing this information with the same color: "+strings.Join(graph.InfoFields, ", "))
+	buildCmd.Flags().BoolVar(&opEdgeColor, "edge-color-by-distance", false, "Color relationships from red to light blue by relational distance")
+	buildCmd.Flags().BoolVar(&opRelLabels, "relationship-labels", false, "Label relationships with the relationships found at their relational distance")
+	buildCmd.Flags().StringVar(&fRelLabels, "relationship-labels-from", "", "CSV of Distance,Label pairs to label relationships with in place of --relationship-labels")
+	buildCmd.Flags().BoolVar(&opEdgeWidth, "edge-width-by-support", false, "Widen relationships by how many pairs of individuals have their shortest path through them")
+	buildCmd.Flags().BoolVar(&opTooltips, "edge-tooltip", false, "Describe each relationship and individual in a tooltip, shown on hover in SVG renderings")
+	buildCmd.Flags().UintVar(&opGenerationGap, "generation-gap-years", 0, "Draw first degree relatives at least this many years apart in age as parent-offspring, and others as full siblings")
+	buildCmd.Flags().BoolVar(&opLegend, "legend", false, "Add a key of the colors used by --color-by and --edge-color-by-distance")
+	buildCmd.Flags().BoolVar(&opCompact, "compact", false, "Write only the attributes needed to read the pedigree, dropping styling")
+	buildCmd.Flags().BoolVar(&opCategoryTopology, "category-topology", false, "Link full siblings through two shared unknown parents rather than one")
+	buildCmd.Flags().BoolVar(&opShareUnknowns, "share-unknowns", false, "Reuse an unknown parent of siblings for relatives equally related to each, rather than drawing new unknowns")
+	buildCmd.Flags().BoolVar(&opDistanceWeights, "distance-weights", false, "Weight relationships by the generations their relatedness implies, rather than inverse relatedness")
+	buildCmd.Flags().Float64Var(&opRelPower, "relatedness-power", 1, "Raise relatedness to this power before weighting, above 1 to more strongly prefer close relationships")
+	buildCmd.Flags().BoolVar(&opRelSquared, "weight-by-relatedness-squared", false, "Square relatedness before weighting, the same as --relatedness-power 2")
+	buildCmd.Flags().BoolVar(&opNoMergeRecip, "no-merge-reciprocal", false, "Keep (A,B) and (B,A) relatedness rows apart, linking each pair from both sides")
+	buildCmd.Flags().BoolVar(&opAssumeSymmetric, "assume-symmetric", false, "Warn when (A,B) and (B,A) relatedness disagree, failing under --strict")
+	buildCmd.Flags().StringSliceVar(&opRelCols, "relatedness-columns", nil, "Columns of relatedness input to read in place of Rel, combined by --aggregate or else their mean (e.g. Wang,Ritland)")
+	buildCmd.Flags().StringVar(&opCarryCol, "carry-column", "", "Column of relatedness input kept with each relationship drawn, to trace it back to its row")
+	buildCmd.Flags().StringVar(&opMarkersCol, "weight-by-markers", "", "Column of marker counts in relatedness input, raising the weight of pairs estimated from fewer markers")
+	buildCmd.Flags().BoolVar(&opFlagDuplicates, "flag-duplicates", false, "Warn of pairs related closely enough to be the same individual sampled twice")
+	buildCmd.Flags().BoolVar(&opMergeDuplicates, "merge-duplicates", false, "Merge likely duplicates into one individual, named by the first of their IDs")
+	buildCmd.Flags().Float64Var(&opDupThreshold, "duplicate-threshold", 0.9, "Relatedness at and above which pairs are likely duplicates")
+	buildCmd.Flags().StringVar(&opAggregate, "aggregate", "last", "How repeated relatedness for a pair is combined: "+strings.Join(relatedness.Aggregates, ", "))
+	buildCmd.Flags().IntVar(&opMaxUnknownChain, "max-unknown-chain", 0, "Draw at most this many unknowns per relationship, labeling one link with the distance of the rest (0 for no limit)")
+	buildCmd.Flags().StringVar(&opWeightScheme, "weight-scheme", "equal", "How relationship weight is spread across unknowns: equal, geometric, or endpoints")
+	buildCmd.Flags().StringVar(&opIDDelimiter, "id-delimiter", "", "Split IDs into family and individual at this delimiter (e.g. _ for FAM1_IND3)")
+	buildCmd.Flags().StringVar(&opSplitBy, "split-by", "", "Also write a separate pedigree of the individuals sharing each value of this information, unlinked across values: "+strings.Join(graph.InfoFields, ", "))
+	buildCmd.Flags().StringVar(&opBridgeBy, "bridge-by", "", "Join separate groups of individuals sharing a value of this information through an assumed unknown ancestor: "+strings.Join(graph.InfoFields, ", "))
+	buildCmd.Flags().StringVar(&opClusterBy, "cluster-by", "", "Box individuals sharing this information together: "+strings.Join(graph.InfoFields, ", "))
+	buildCmd.Flags().StringVar(&opRankBy, "rank-by", "", "Align individuals sharing this information on the same rank, rather than age: "+strings.Join(graph.InfoFields, ", "))
+	buildCmd.Flags().StringVar(&opUnknownPrefix, "unknown-prefix", "", "Prefix the name, and label, of each inferred unknown individual")
+	buildCmd.Flags().IntVar(&opMinComponentSize, "min-component-size", 0, "Remove groups of connected individuals with fewer than this many knowns")
+	buildCmd.Flags().BoolVar(&opPruneIsolated, "prune-isolated-unknowns", false, "Remove unknowns not on a path between two individuals after pruning")
+	buildCmd.Flags().BoolVar(&opKeepDisconnected, "keep-disconnected", false, "Keep individuals left without links after pruning")
+	buildCmd.Flags().IntVar(&opMaxEdges, "max-edges", 0, "Stop when the pruned pedigree has more relationships than this, 0 for no limit")
+	buildCmd.Flags().BoolVar(&opTrimEdges, "trim-to-max-edges", false, "Keep the strongest relationships up to --max-edges rather than stop")
+	buildCmd.Flags().BoolVar(&opMkdir, "mkdir", false, "Create missing directories of output files")
+	buildCmd.Flags().StringVar(&fCacheDir, "cache-dir", "", "Directory to save the pruned pedigree in for --resume")
+	buildCmd.Flags().BoolVar(&opResume, "resume", false, "Reuse the pruned pedigree saved in --cache-dir by a run with the same inputs and options")
+	buildCmd.Flags().IntVar(&opThreads, "threads", runtime.NumCPU(), "Number of individuals to search from at once while pruning")
+	buildCmd.Flags().DurationVar(&opTimeout, "timeout", 0, "Stop pruning after this long (e.g. 90s, 2h), writing the partial pedigree")
+	buildCmd.Flags().StringVar(&opPruneStrategy, "prune-strategy", pruneStrategies[0], "How to prune relationships: shortest paths between individuals, or a backbone of the most central links by betweenness")
+	buildCmd.Flags().Int64Var(&opSeed, "seed", 0, "Name unknowns from this seed so the same input gives the same output every run (0 for unique names)")
+	buildCmd.Flags().BoolVar(&opStableIDs, "stable-ids", false, "Derive internal node IDs from names so they are the same across runs")
+	buildCmd.Flags().StringSliceVar(&opExplain, "explain", nil, "Print how two individuals are related in the pedigree (e.g. --explain ID1,ID2)")
+	buildCmd.Flags().StringVar(&opLayoutEngine, "layout-engine", "", "Graphviz engine to lay out the pedigree with: "+strings.Join(pedigree.LayoutEngines, ", "))
+	buildCmd.Flags().BoolVar(&opSimpleLayout, "simple-layout", false, "Use default Graphviz splines rather than orthogonal splines")
+
+	// CSV parsing
+	buildCmd.Flags().BoolVar(&opLazyQuotes, "lazy-quotes", false, "Allow quotes in unquoted fields and non-doubled quotes in quoted fields")
+	buildCmd.Flags().StringVar(&opCommentChar, "comment-char", "", "Ignore input lines beginning with this character")
+	buildCmd.Flags().BoolVar(&opHeader, "header", false, "Always read the first row of relatedness input as a header, rather than detecting one")
+	buildCmd.Flags().BoolVar(&opNoHeader, "no-header", false, "Always read the first row of relatedness input as data, rather than detecting a header")
+	buildCmd.Flags().BoolVar(&opNoTrimIDs, "no-trim-ids", false, "Keep white space around relatedness IDs rather than removing it")
+	buildCmd.Flags().BoolVar(&opTrimLeadingSpace, "trim-leading-space", false, "Ignore leading white space in input fields")
 }
 
 // setup runs the CLI initialization prior to program logic
-func setup() {
+func setup(flags *pflag.FlagSet) {
+	// Set rounding before any relatedness is converted to distance
+	if mode, err := util.ParseRounding(opRounding); err == nil {
+		rounding = mode
+	} else {
+		exit.Fatalf(exit.Usage, "%s\n", err)
+	}
+
 	// Set minDist
 	if val, err := strconv.ParseFloat(opMinRelatedness, 64); err == nil {
-		minDist = util.RelToLevel(val)
+		minDist = util.RelToLevel(val, rounding)
 	} else {
 		minDist = util.CategoryToDist(opMinRelatedness)
 	}
-	fmt.Println(minDist)
+	log.Debugf("Minimum relational distance: %d\n", minDist)
+
+	// Set weightScheme
+	if scheme, err := graph.ParseWeightScheme(opWeightScheme); err == nil {
+		weightScheme = scheme
+	} else {
+		exit.Fatalf(exit.Usage, "%s\n", err)
+	}
+
+	// Set CSV parsing options
+	csvOpts = util.CsvOptions{
+		LazyQuotes:       opLazyQuotes,
+		TrimLeadingSpace: opTrimLeadingSpace,
+	}
+	if opCommentChar != "" {
+		comment := []rune(opCommentChar)
+		if len(comment) != 1 || strings.ContainsRune("\",\r\n", comment[0]) {
+			exit.Fatalf(exit.Usage, "--comment-char must be a single character other than a comma, quote, or newline, got %q\n", opCommentChar)
+		}
+		csvOpts.Comment = comment[0]
+	}
+	precision = util.Places(opPrecision)
+
+	checkInfoField("cluster-by", opClusterBy)
+	checkInfoField("split-by", opSplitBy)
+	checkInfoField("bridge-by", opBridgeBy)
+	if _, ok := formatExts[opFormat]; !ok {
+		exit.Fatalf(exit.Usage, "--format must be dot, gexf, html, matrix, ped, kinship2, or induced.\n")
+	}
+	for _, format := range opFormats {
+		if _, ok := formatExts[format]; !ok {
+			exit.Fatalf(exit.Usage, "--formats must each be dot, gexf, html, matrix, ped, kinship2, or induced, got %q.\n", format)
+		}
+	}
+	if len(opFormats) != 0 && flags.Changed("format") {
+		exit.Fatalf(exit.Usage, "Use only one of --format and --formats.\n")
+	}
+	if fRender != "" && outputName("dot") == "" {
+		exit.Fatalf(exit.Usage, "--also-render requires --format dot.\n")
+	}
+	if fLayout != "" && outputName("dot") == "" {
+		exit.Fatalf(exit.Usage, "--save-layout requires --format dot.\n")
+	}
+	if opInferDirection != "" && !graph.IsInfoField(opInferDirection) {
+		exit.Fatalf(exit.Usage, "--infer-direction-from must be one of: %s\n", strings.Join(graph.InfoFields, ", "))
+	}
+	if !relatedness.IsAggregate(opAggregate) {
+		exit.Fatalf(exit.Usage, "--aggregate must be one of: %s\n", strings.Join(relatedness.Aggregates, ", "))
+	}
+	if opColorBy != "" && !graph.IsInfoField(opColorBy) {
+		exit.Fatalf(exit.Usage, "--color-by must be one of: %s\n", strings.Join(graph.InfoFields, ", "))
+	}
+	if opLegend && opColorBy == "" && !opEdgeColor {
+		exit.Fatalf(exit.Usage, "--legend requires --color-by or --edge-color-by-distance.\n")
+	}
+	if opRankBy != "" && !graph.IsInfoField(opRankBy) {
+		exit.Fatalf(exit.Usage, "--rank-by must be one of: %s\n", strings.Join(graph.InfoFields, ", "))
+	}
+
+	if opLayoutEngine != "" && !pedigree.IsLayoutEngine(opLayoutEngine) {
+		exit.Fatalf(exit.Usage, "--layout-engine must be one of: %s\n", strings.Join(pedigree.LayoutEngines, ", "))
+	}
 
 	// Information states
-	// None
+	// Presets stand in for --max-distance unless it is given
+	if opPreset != "" {
+		dist, ok := presets[opPreset]
+		if !ok {
+			exit.Fatalf(exit.Usage, "--preset must be one of: %s\n", strings.Join(presetNames, ", "))
+		}
+		if !flags.Changed("max-distance") {
+			opMaxDistance = dist
+			log.Infof("Using --max-distance %d for --preset %s\n", opMaxDistance, opPreset)
+		}
+	}
+	// Set the relatedness input format
+	switch {
+	case opMLRelate && flags.Changed("input-format") && opInputFormat != relatedness.MLRelateFormat:
+		exit.Fatalf(exit.Usage, "--ml-relate cannot be used with --input-format %s.\n", opInputFormat)
+	case opInputFormat == "auto":
+		if fRelatedness != "" {
+			opMLRelate = detectFormat() == relatedness.MLRelateFormat
+		}
+	case opInputFormat == relatedness.MLRelateFormat:
+		opMLRelate = true
+	case opInputFormat != relatedness.ThreeColumnFormat:
+		exit.Fatalf(exit.Usage, "--input-format must be auto or one of: %s\n", strings.Join(relatedness.Formats, ", "))
+	}
+	// ML-Relate relationships are no further than third degree
+	if opMLRelate && !flags.Changed("max-distance") && mlRelateMaxDistance < opMaxDistance {
+		opMaxDistance = mlRelateMaxDistance
+		log.Infof("Using --max-distance %d for ML-Relate input\n", opMaxDistance)
+	}
+	if opRelSquared {
+		if flags.Changed("relatedness-power") {
+			exit.Fatalf(exit.Usage, "Use only one of --relatedness-power and --weight-by-relatedness-squared.\n")
+		}
+		opRelPower = 2
+	}
 
 	// Warning states
 	// None
 
 	// Failure states
 	switch {
+	case opExplain != nil && len(opExplain) != 2:
+		exit.Fatalf(exit.Usage, "--explain takes exactly two IDs, got %d.\n", len(opExplain))
+	case opRelationshipsCol != "" && opRelationshipsCol != "closest" && opRelationshipsCol != "furthest":
+		exit.Fatalf(exit.Usage, "--relationships-column must be closest or furthest.\n")
+	case opRelationshipsCol != "" && !opMLRelate:
+		exit.Fatalf(exit.Usage, "--relationships-column requires --ml-relate.\n")
+	case opCategorical && opMLRelate:
+		exit.Fatalf(exit.Usage, "Use only one of --input-categorical and --ml-relate.\n")
+	case opMLProbabilities && !opMLRelate:
+		exit.Fatalf(exit.Usage, "--ml-relate-probabilities requires --ml-relate.\n")
+	case opMLColumns != relatedness.ColumnNumbers{} && !opMLRelate:
+		exit.Fatalf(exit.Usage, "--ml-col-ind1, --ml-col-ind2, --ml-col-category, and --ml-col-relatedness require --ml-relate.\n")
+	case len(opRelCols) != 0 && opMLRelate:
+		exit.Fatalf(exit.Usage, "Use only one of --relatedness-columns and --ml-relate.\n")
+	case len(opRelCols) != 0 && (opCategorical || opIntervals):
+		exit.Fatalf(exit.Usage, "--relatedness-columns must be numbers, not --input-categorical or --intervals.\n")
+	case opHeader && opNoHeader:
+		exit.Fatalf(exit.Usage, "Use only one of --header and --no-header.\n")
+	case opNormalize && opNormalizeData:
+		exit.Fatalf(exit.Usage, "Use only one of --normalize and --normalize-to-data.\n")
+	case opPrecision < -1:
+		exit.Fatalf(exit.Usage, "--precision must be -1 or more.\n")
+	case opResume && fCacheDir == "":
+		exit.Fatalf(exit.Usage, "--resume requires --cache-dir.\n")
+	case opDupThreshold <= 0 || 1 < opDupThreshold:
+		exit.Fatalf(exit.Usage, "--duplicate-threshold must be above 0 and at most 1.\n")
+	case !(0 < opRelPower) || math.IsInf(opRelPower, 1):
+		exit.Fatalf(exit.Usage, "--relatedness-power must be a finite number above 0.\n")
+	case !isPruneStrategy(opPruneStrategy):
+		exit.Fatalf(exit.Usage, "--prune-strategy must be one of: %s\n", strings.Join(pruneStrategies, ", "))
+	case opPruneStrategy == "betweenness" && 0 < opTimeout:
+		exit.Fatalf(exit.Usage, "--timeout requires --prune-strategy shortest.\n")
+	case opMinComponentSize < 0:
+		exit.Fatalf(exit.Usage, "--min-component-size must be 0 or more.\n")
+	case opGenerationGap != 0 && fDemographics == "":
+		exit.Fatalf(exit.Usage, "--generation-gap-years requires --demographics.\n")
+	case opMaxEdges < 0:
+		exit.Fatalf(exit.Usage, "--max-edges must be 0 or more.\n")
+	case opTrimEdges && opMaxEdges == 0:
+		exit.Fatalf(exit.Usage, "--trim-to-max-edges requires --max-edges.\n")
+	case opMaxUnknownChain < 0:
+		exit.Fatalf(exit.Usage, "--max-unknown-chain must be 0 or more.\n")
+	case opThreads < 1:
+		exit.Fatalf(exit.Usage, "--threads must be at least 1.\n")
+	case opMLRelate && mlRelateMaxDistance < opMaxDistance:
+		exit.Fatalf(exit.Usage, "--max-distance must be %d or less with --ml-relate.\n", mlRelateMaxDistance)
+	case opMaxDistance == 0 || uint(relational.Ninth) < opMaxDistance:
+		exit.Fatalf(exit.Usage, "--max-distance must be between 1 and %d.\n", relational.Ninth)
 	case fOut == "":
 		pflag.Usage()
-		log.Fatalf("Must provide --output.\n")
+		exit.Fatalf(exit.Usage, "Must provide --output.\n")
 	case fRelatedness == "":
 		pflag.Usage()
-		log.Fatalf("Must provide --relatedness.\n")
+		exit.Fatalf(exit.Usage, "Must provide --relatedness.\n")
 	}
 }
 
-func build() {
+func build(flags *pflag.FlagSet) {
+	start := time.Now()
+
 	// Parse CLI arguments
-	setup()
+	setup(flags)
 
 	var (
 		input relatedness.CsvInput
@@ -113,26 +475,64 @@ func build() {
 	in, err := os.Open(fRelatedness)
 	defer in.Close()
 	if err != nil {
-		log.Fatalf("Could not read input file: %s\n", err)
+		exit.Fatalf(exit.IO, "Could not read input file: %s\n", err)
 	}
-	out, err := os.Create(fOut)
-	defer out.Close()
-	if err != nil {
-		log.Fatalf("Could not create output file: %s\n", err)
+	outs := make(map[string]*os.File)
+	for _, format := range outputFormats() {
+		out, err := createOutput(outputName(format))
+		if err != nil {
+			exit.Fatalf(exit.IO, "Could not create output file: %s\n", err)
+		}
+		defer out.Close()
+		outs[format] = out
 	}
 
 	// Read in CSV input
-	input = relatedness.NewThreeColumnCsv(in, opNormalize)
+	relOpts := relatedness.Options{
+		Normalize:       opNormalize,
+		NormalizeToData: opNormalizeData,
+		Strict:          opStrict,
+		CollectErrors:   opCollectErrors,
+		MergeReciprocal: !opNoMergeRecip,
+		Aggregate:       opAggregate,
+		AssumeSymmetric: opAssumeSymmetric,
+		MarkersColumn:   opMarkersCol,
+		CarryColumn:     opCarryCol,
+		Percent:         opPercent,
+		Intervals:       opIntervals,
+		Categorical:     opCategorical,
+		TrimIDs:         !opNoTrimIDs,
+		Header:          headerMode(),
+		CSV:             csvOpts,
+		Rounding:        rounding,
+
+		RelatednessColumns:    opRelCols,
+		MLRelateRelationships: opRelationshipsCol,
+		MLRelateProbabilities: opMLProbabilities,
+		MLRelateColumns:       opMLColumns,
+	}
+	if !flags.Changed("aggregate") {
+		relOpts.Aggregate = "" // Columns are averaged unless told otherwise
+	}
+	if opMLRelate {
+		input = relatedness.NewMLRelateCsv(in, relOpts)
+	} else {
+		input = relatedness.NewThreeColumnCsv(in, relOpts)
+	}
+	if opFlagDuplicates || opMergeDuplicates {
+		input = reportDuplicates(input)
+	}
 	indvs := input.Indvs()
+	reportDistances(input)
 
 	// Open demographics file
 	if fDemographics != "" {
 		inDem, err := os.Open(fDemographics)
 		defer inDem.Close()
 		if err != nil {
-			log.Fatalf("Could not read demographics file: %s\n", err)
+			exit.Fatalf(exit.IO, "Could not read demographics file: %s\n", err)
 		}
-		dems = demographics.NewThreeColumnCsv(inDem)
+		dems = demographics.NewThreeColumnCsv(util.NewCsvReader(inDem, csvOpts))
 	}
 
 	// Open parentage file
@@ -140,14 +540,14 @@ func build() {
 		inPar, err := os.Open(fParentage)
 		defer inPar.Close()
 		if err != nil {
-			log.Fatalf("Could not read parentage file: %s\n", err)
+			exit.Fatalf(exit.IO, "Could not read parentage file: %s\n", err)
 		}
-		pars = parentage.NewThreeColumnCsv(inPar)
+		pars = parentage.NewThreeColumnCsv(util.NewCsvReader(inPar, csvOpts))
 	}
 
 	// Check demographics and parentage for consistency
 	if msg := util.DemsAndParsAgree(dems, pars); msg != "" {
-		log.Fatalf("The demographics and parentage files disagree:\n%s", msg)
+		exit.Fatalf(exit.Validation, "The demographics and parentage files disagree:\n%s", msg)
 	}
 
 	// Issue #30: If there is an ID in optional files, but not in required files then error
@@ -181,33 +581,590 @@ func build() {
 		}
 	}
 	if errored {
-		log.Fatalf("Cancelled further processing due to previous errors\n")
+		exit.Fatalf(exit.Validation, "Cancelled further processing due to previous errors\n")
 	}
 
-	// Build graph
-	g := graph.NewGraphFromCsvInput(input, minDist, pars, dems)
+	// Build graph, or restore it from a previous run
+	var g *graph.Graph
+	timedOut := false
+	cacheFile := ""
+	if fCacheDir != "" {
+		cacheFile = cachePath(fCacheDir, flags)
+	}
+	if opResume {
+		g = loadCache(cacheFile)
+	}
+	if g == nil {
+		g, timedOut = buildGraph(input, pars, dems)
+		if cacheFile != "" && !timedOut {
+			saveCache(cacheFile, g)
+		}
+	}
 
-	// Prune edges to only the shortest between two knowns
-	g.Prune()
+	if opValidate {
+		validate(g)
+	}
 
 	// Write the outout
 	strIndvs := make([]string, 0, indvs.Cardinality())
 	for _, indv := range indvs.ToSlice() {
 		strIndvs = append(strIndvs, indv.(string))
 	}
-	ped, unmapped := pedigree.NewPedigreeFromGraph(g, strIndvs, opRmArrows)
+	var inbred map[string]float64
+	if opSelfInbreeding {
+		inbred = make(map[string]float64)
+		for _, indv := range strIndvs {
+			if self := input.Relatedness(indv, indv); 0 < self {
+				inbred[indv] = float64(self)
+			}
+		}
+	}
+	ped, unmapped := newPedigree(g, strIndvs, inbred)
 	if fUnmapped != "" {
 		if unmapped != nil {
-			un, err := os.Create(fUnmapped)
+			un, err := createOutput(fUnmapped)
 			defer un.Close()
 			if err != nil {
-				log.Fatalf("Could not create output file: %s\n", err)
+				exit.Fatalf(exit.IO, "Could not create output file: %s\n", err)
+			}
+			if _, err := un.WriteString(strings.Join(unmapped, "\n")); err != nil {
+				exit.Fatalf(exit.IO, "Could not write unmapped file: %s\n", err)
 			}
-			un.WriteString(strings.Join(unmapped, "\n"))
 		} else {
 			log.Infof("No unmapped individuals\n")
 		}
 	}
-	out.WriteString(ped.String())
+	if opExplain != nil {
+		if exp, err := g.Explain(opExplain[0], opExplain[1]); err == nil {
+			fmt.Print(exp.Describe(g.IsKnown))
+		} else {
+			log.Errorf("Could not explain relationship: %s\n", err)
+		}
+	}
+	if fImputed != "" {
+		writeImputed(fImputed, g.Impute(input.Pairs()))
+	}
+	if fAmbiguous != "" {
+		writeAmbiguous(fAmbiguous, g.Ambiguous())
+	}
+	if fTrace != "" {
+		writeTrace(fTrace, g)
+	}
+	if fUnknowns != "" {
+		writeUnknowns(fUnknowns, g.Unknowns())
+	}
+	if fDetours != "" || log.IsLevelEnabled(log.DebugLevel) {
+		reportDetours(fDetours, g.Detours(input, minDist, relational.Degree(opMaxDistance)))
+	}
+	if fIndividuals != "" || fDropped != "" {
+		writeIndividuals(fIndividuals, fDropped, input, g, strIndvs)
+	}
+	if fInbreeding != "" {
+		writeInbreeding(fInbreeding, g.Inbreeding())
+	}
+	writeFormats(outs, g, ped)
+	if opSplitBy != "" {
+		writePartitions(g, inbred)
+	}
+	if fSummary != "" {
+		writeSummary(fSummary, flags, input, g, start)
+	}
+	if fRender != "" {
+		render(outputName("dot"), fRender, opLayoutEngine)
+	}
+	if fLayout != "" {
+		saveLayout(outputName("dot"), fLayout, opLayoutEngine)
+	}
+	if timedOut {
+		os.Exit(exit.Timeout)
+	}
 	return
 }
+
+// newPedigree draws g with the individuals indvs, returning those not drawn
+func newPedigree(g *graph.Graph, indvs []string, inbred map[string]float64) (*pedigree.Pedigree, []string) {
+	ped, unmapped := pedigree.NewPedigreeFromGraph(g, indvs, pedigree.Options{
+		Undirected: opRmArrows,
+		ClusterBy:  opClusterBy,
+
+		UnknownLabel: opUnknownPrefix,
+		RankBy:       opRankBy,
+		Inbred:       inbred,
+
+		InferDirectionFrom: opInferDirection,
+		Compact:            opCompact,
+		ColorBy:            opColorBy,
+		Legend:             opLegend,
+		ColorByDistance:    opEdgeColor,
+		WidthBySupport:     opEdgeWidth,
+		Tooltips:           opTooltips,
+		GenerationGap:      opGenerationGap,
+		Precision:          precision,
+		RelationshipLabels: relationshipLabels(),
+	})
+	switch {
+	case opSimpleLayout, opCompact:
+		ped.SimpleLayout()
+	case pedigree.TooComplexForOrtho(g):
+		log.Warnf("Pedigree is too large for orthogonal splines (%d nodes, %d edges), using --simple-layout\n", g.Nodes().Len(), g.Edges().Len())
+		ped.SimpleLayout()
+	}
+	if opLayoutEngine != "" {
+		ped.SetLayout(opLayoutEngine)
+	}
+	return ped, unmapped
+}
+
+// writeFormats writes g, drawn as ped, in each output format to outs,
+// closing each
+func writeFormats(outs map[string]*os.File, g *graph.Graph, ped *pedigree.Pedigree) {
+	for _, format := range outputFormats() {
+		out := outs[format]
+		switch format {
+		case "matrix":
+			writeMatrix(out, g)
+		case "gexf":
+			if err := gexf.Write(out, g, time.Now().Year(), precision); err != nil {
+				exit.Fatalf(exit.IO, "Could not write GEXF: %s\n", err)
+			}
+		case "html":
+			if err := html.Write(out, g, precision); err != nil {
+				exit.Fatalf(exit.IO, "Could not write HTML: %s\n", err)
+			}
+		case "ped":
+			if err := linkage.Write(out, g); err != nil {
+				exit.Fatalf(exit.IO, "Could not write PED: %s\n", err)
+			}
+		case "kinship2":
+			if err := linkage.WriteKinship2(out, g); err != nil {
+				exit.Fatalf(exit.IO, "Could not write kinship2 CSV: %s\n", err)
+			}
+		case "induced":
+			if _, err := out.WriteString(pedigree.NewInducedPedigree(g, relationshipLabels()).String()); err != nil {
+				exit.Fatalf(exit.IO, "Could not write output file: %s\n", err)
+			}
+		default:
+			if _, err := out.WriteString(ped.String()); err != nil {
+				exit.Fatalf(exit.IO, "Could not write output file: %s\n", err)
+			}
+		}
+		if err := out.Close(); err != nil {
+			exit.Fatalf(exit.IO, "Could not write output file: %s\n", err)
+		}
+	}
+}
+
+// relationshipLabels are the labels of relationships by distance,
+// read from --relationship-labels-from or else the default names
+// under --relationship-labels
+func relationshipLabels() map[relational.Degree]string {
+	switch {
+	case fRelLabels != "":
+		return readRelationshipLabels(fRelLabels)
+	case opRelLabels:
+		return pedigree.RelationshipNames
+	default:
+		return nil
+	}
+}
+
+// readRelationshipLabels reads a CSV of Distance,Label pairs,
+// skipping a header
+func readRelationshipLabels(name string) map[relational.Degree]string {
+	f, err := os.Open(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not read relationship labels file: %s\n", err)
+	}
+	defer f.Close()
+	records, err := util.NewCsvReader(f, csvOpts).ReadAll()
+	if err != nil {
+		exit.Fatalf(exit.Parse, "Misread in relationship labels CSV: %s\n", err)
+	}
+	labels := make(map[relational.Degree]string, len(records))
+	for i, record := range records {
+		if len(record) != 2 {
+			exit.Fatalf(exit.Parse, "Misread in relationship labels CSV: line %d has %d columns rather than 2\n", i+1, len(record))
+		}
+		dist, err := strconv.ParseUint(strings.TrimSpace(record[0]), 10, 0)
+		switch {
+		case err != nil && i == 0:
+			continue // Header
+		case err != nil || dist == 0 || uint64(relational.Ninth) < dist:
+			exit.Fatalf(exit.Parse, "Misread in relationship labels CSV: line %d distance %q is not between 1 and %d\n", i+1, record[0], relational.Ninth)
+		}
+		labels[relational.Degree(dist)] = record[1]
+	}
+	return labels
+}
+
+// isPruneStrategy reports whether name is one of pruneStrategies
+func isPruneStrategy(name string) bool {
+	for _, strategy := range pruneStrategies {
+		if name == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// checkInfoField stops unless field, given to flag, is empty or one of
+// graph.InfoFields with the input it comes from
+func checkInfoField(flag, field string) {
+	switch {
+	case field == "":
+	case !graph.IsInfoField(field):
+		exit.Fatalf(exit.Usage, "--%s must be one of: %s\n", flag, strings.Join(graph.InfoFields, ", "))
+	case field == "family" && opIDDelimiter == "":
+		exit.Fatalf(exit.Usage, "--%s family requires --id-delimiter.\n", flag)
+	case (field == "sex" || field == "age") && fDemographics == "":
+		exit.Fatalf(exit.Usage, "--%s %s requires --demographics.\n", flag, field)
+	case (field == "sire" || field == "dam") && fParentage == "":
+		exit.Fatalf(exit.Usage, "--%s %s requires --parentage.\n", flag, field)
+	}
+}
+
+// detectFormat detects the format of --relatedness
+func detectFormat() string {
+	f, err := os.Open(fRelatedness)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not read input file: %s\n", err)
+	}
+	defer f.Close()
+	format, err := relatedness.DetectFormat(f, relatedness.Options{Header: headerMode(), CSV: csvOpts})
+	if err != nil {
+		exit.Fatalf(exit.Parse, "Could not detect the format of %s: %s\n", fRelatedness, err)
+	}
+	log.Infof("Reading %s as %s input\n", fRelatedness, format)
+	return format
+}
+
+// headerMode is how relatedness input headers are found
+func headerMode() relatedness.HeaderMode {
+	switch {
+	case opHeader:
+		return relatedness.WithHeader
+	case opNoHeader:
+		return relatedness.WithoutHeader
+	default:
+		return relatedness.DetectHeader
+	}
+}
+
+// formatExts are the file extensions of each output format
+var formatExts = map[string]string{
+	"dot":      ".dot",
+	"gexf":     ".gexf",
+	"html":     ".html",
+	"matrix":   ".csv",
+	"ped":      ".ped",
+	"kinship2": ".kinship2.csv",
+	"induced":  ".induced.dot",
+}
+
+// outputFormats are the formats to write, --formats or else --format
+func outputFormats() []string {
+	if len(opFormats) != 0 {
+		return opFormats
+	}
+	return []string{opFormat}
+}
+
+// outputName is the file format is written to, --output itself
+// unless writing several formats, or empty if format is not written
+func outputName(format string) string {
+	if len(opFormats) == 0 {
+		if format == opFormat {
+			return fOut
+		}
+		return ""
+	}
+	for _, f := range opFormats {
+		if f == format {
+			return strings.TrimSuffix(fOut, filepath.Ext(fOut)) + formatExts[format]
+		}
+	}
+	return ""
+}
+
+// createOutput creates the output file name, first creating any missing
+// directories under --mkdir
+func createOutput(name string) (*os.File, error) {
+	if opMkdir {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Create(name)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s (add --mkdir to create missing directories)", err)
+	}
+	return f, err
+}
+
+// buildGraph builds the graph from input and prunes it,
+// reporting whether pruning stopped early
+func buildGraph(input relatedness.CsvInput, pars parentage.CsvInput, dems demographics.CsvInput) (*graph.Graph, bool) {
+	g := graph.NewGraphFromCsvInput(input, minDist, pars, dems, graph.Options{
+		Scheme:      weightScheme,
+		IDDelimiter: opIDDelimiter,
+		StableIDs:   opStableIDs,
+		MaxDist:     relational.Degree(opMaxDistance),
+
+		UnknownPrefix: opUnknownPrefix,
+		Threads:       opThreads,
+
+		CategoryTopology: opCategoryTopology,
+		DistanceWeights:  opDistanceWeights,
+		RelatednessPower: opRelPower,
+		MergeReciprocal:  !opNoMergeRecip,
+		MaxUnknownChain:  opMaxUnknownChain,
+		Seed:             opSeed,
+		WeightByMarkers:  opMarkersCol != "",
+		SplitBy:          opSplitBy,
+		Trace:            fTrace != "",
+		ShareUnknowns:    opShareUnknowns,
+		Rounding:         rounding,
+	})
+	if opSplitBy != "" {
+		reportCrossPartition(input, g)
+	}
+
+	// Prune edges to only the shortest between two knowns
+	ctx := context.Background()
+	if 0 < opTimeout {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opTimeout)
+		defer cancel()
+	}
+	timedOut := false
+	switch opPruneStrategy {
+	case "betweenness":
+		g.PruneBetweenness()
+	default:
+		if err := g.PruneContext(ctx); err != nil {
+			log.Warnf("Pruning stopped after --timeout %s, pedigree is partial\n", opTimeout)
+			timedOut = true
+		}
+	}
+	if opPruneIsolated {
+		g.RmIsolatedUnknowns()
+	}
+	if 1 < opMinComponentSize {
+		if comps, knowns := g.RmSmallComponents(opMinComponentSize); comps != 0 {
+			log.Infof("Removed %d components with fewer than %d known individuals (%d individuals)\n", comps, opMinComponentSize, knowns)
+		}
+	}
+	if n := g.Edges().Len(); 0 < opMaxEdges && opMaxEdges < n {
+		if !opTrimEdges {
+			exit.Fatalf(exit.Validation, "Pruned pedigree has %d relationships, more than --max-edges %d; lower --max-distance or add --trim-to-max-edges\n", n, opMaxEdges)
+		}
+		removed := g.KeepLightest(opMaxEdges)
+		log.Warnf("Pruned pedigree had %d relationships, kept the strongest %d for --max-edges %d\n", n, n-removed, opMaxEdges)
+	}
+	if !opKeepDisconnected {
+		if n := g.RmDisconnected(); n != 0 {
+			log.Infof("Removed %d individuals left without links after pruning\n", n)
+		}
+	}
+	if opBridgeBy != "" {
+		if n := g.BridgeBy(opBridgeBy, opUnknownPrefix); n != 0 {
+			log.Infof("Bridged %d values of %s shared across separate groups through assumed unknown ancestors\n", n, opBridgeBy)
+		}
+	}
+	return g, timedOut
+}
+
+// validate reports individuals with impossibly many unknown parents
+func validate(g *graph.Graph) {
+	invalid := g.Validate()
+	for _, inv := range invalid {
+		log.Warnf("%s is linked to %d unknown parents: %s\n", inv.ID, len(inv.Unknowns), strings.Join(inv.Unknowns, ", "))
+	}
+	if opStrict && len(invalid) != 0 {
+		exit.Fatalf(exit.Validation, "Pedigree failed validation with %d individuals having more than two parents\n", len(invalid))
+	}
+}
+
+// writeImputed writes imputed relatedness as a CSV
+func writeImputed(name string, imputed []graph.Imputed) {
+	f, err := createOutput(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create imputed relatedness file: %s\n", err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write([]string{"ID1", "ID2", "Distance", "Rel"})
+	for _, imp := range imputed {
+		w.Write([]string{
+			imp.ID1,
+			imp.ID2,
+			strconv.FormatUint(uint64(imp.Distance), 10),
+			util.FormatFloat(float64(imp.Relatedness), precision),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		exit.Fatalf(exit.IO, "Could not write imputed relatedness file: %s\n", err)
+	}
+}
+
+// writeMatrix writes the distance between each pair of individuals as a CSV,
+// with NA for individuals not connected in the pedigree
+func writeMatrix(out io.Writer, g *graph.Graph) {
+	names, dists := g.Distances()
+	w := csv.NewWriter(out)
+	w.Write(append([]string{"ID"}, names...))
+	for i, name := range names {
+		row := make([]string, 0, len(names)+1)
+		row = append(row, name)
+		for j, dist := range dists[i] {
+			if dist == 0 && i != j {
+				row = append(row, "NA")
+			} else {
+				row = append(row, strconv.FormatUint(uint64(dist), 10))
+			}
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		exit.Fatalf(exit.IO, "Could not write distance matrix: %s\n", err)
+	}
+}
+
+// writeInbreeding writes estimated inbreeding coefficients as a CSV
+func writeInbreeding(name string, inbred []graph.Inbred) {
+	f, err := createOutput(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create inbreeding file: %s\n", err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write([]string{"ID", "F_estimate"})
+	for _, in := range inbred {
+		w.Write([]string{in.ID, util.FormatFloat(in.F, precision)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		exit.Fatalf(exit.IO, "Could not write inbreeding file: %s\n", err)
+	}
+}
+
+func writeAmbiguous(name string, ambiguous []graph.Ambiguous) {
+	f, err := createOutput(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create ambiguous pairs file: %s\n", err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write([]string{"ID1", "ID2", "Paths", "Weight"})
+	for _, a := range ambiguous {
+		w.Write([]string{a.ID1, a.ID2, strconv.Itoa(a.Paths), util.FormatFloat(a.Weight, precision)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		exit.Fatalf(exit.IO, "Could not write ambiguous pairs file: %s\n", err)
+	}
+}
+
+// writeUnknowns writes inferred unknowns as a CSV, separating the
+// individuals each links by semicolons
+func writeUnknowns(name string, unknowns []graph.Unknown) {
+	f, err := createOutput(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create unknowns file: %s\n", err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write([]string{"ID", "Links", "Knowns"})
+	for _, u := range unknowns {
+		w.Write([]string{u.ID, strconv.Itoa(len(u.Knowns)), strings.Join(u.Knowns, ";")})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		exit.Fatalf(exit.IO, "Could not write unknowns file: %s\n", err)
+	}
+}
+
+// reportDetours lists pairs drawn at a different distance than their
+// relatedness implies, writing them as a CSV to name when set
+func reportDetours(name string, detours []graph.Detour) {
+	for _, d := range detours {
+		if d.Drawn == 0 {
+			log.Debugf("%s and %s are at relational distance %d, but not connected in the pedigree\n", d.ID1, d.ID2, d.Direct)
+		} else {
+			log.Debugf("%s and %s are at relational distance %d, but drawn %d apart\n", d.ID1, d.ID2, d.Direct, d.Drawn)
+		}
+	}
+	if name == "" {
+		return
+	}
+	f, err := createOutput(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create detours file: %s\n", err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write([]string{"ID1", "ID2", "Direct", "Drawn"})
+	for _, d := range detours {
+		drawn := "NA"
+		if d.Drawn != 0 {
+			drawn = strconv.FormatUint(uint64(d.Drawn), 10)
+		}
+		w.Write([]string{d.ID1, d.ID2, strconv.FormatUint(uint64(d.Direct), 10), drawn})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		exit.Fatalf(exit.IO, "Could not write detours file: %s\n", err)
+	}
+}
+
+// reportDuplicates warns of likely duplicates in input,
+// merging them under --merge-duplicates
+func reportDuplicates(input relatedness.CsvInput) relatedness.CsvInput {
+	dups := relatedness.Duplicates(input, opDupThreshold)
+	for _, dup := range dups {
+		log.Warnf("%q and %q are likely duplicates with relatedness %s\n", dup.ID1, dup.ID2, util.FormatFloat(float64(dup.Relatedness), precision))
+	}
+	if len(dups) == 0 {
+		log.Infof("No pairs are likely duplicates at --duplicate-threshold %v\n", opDupThreshold)
+		return input
+	}
+	log.Warnf("%d pairs are likely duplicates at --duplicate-threshold %v\n", len(dups), opDupThreshold)
+	if !opMergeDuplicates {
+		return input
+	}
+	merged := relatedness.MergeDuplicates(input, dups)
+	log.Infof("Merged %d individuals into likely duplicates\n", input.Indvs().Cardinality()-merged.Indvs().Cardinality())
+	return merged
+}
+
+// reportDistances compares --max-distance to the distances in the input
+func reportDistances(input relatedness.CsvInput) {
+	var maxObserved relational.Degree
+	related, excluded := 0, 0
+	for _, pair := range input.Pairs() {
+		dist := input.RelDistance(pair[0], pair[1])
+		if dist == relational.Unrelated {
+			continue
+		}
+		related++
+		if maxObserved < dist {
+			maxObserved = dist
+		}
+		if relational.Degree(opMaxDistance) < dist {
+			excluded++
+		}
+	}
+	log.Debugf("Maximum relational distance in input: %d\n", maxObserved)
+	if maxObserved < relational.Degree(opMaxDistance) {
+		log.Debugf("--max-distance %d is higher than needed, no pair is further than %d\n", opMaxDistance, maxObserved)
+	}
+	switch {
+	case excluded == 0:
+	case related <= 10*excluded:
+		log.Warnf("%d of %d related pairs are further than --max-distance %d, consider raising it\n", excluded, related, opMaxDistance)
+	default:
+		log.Debugf("%d of %d related pairs are further than --max-distance %d\n", excluded, related, opMaxDistance)
+	}
+}
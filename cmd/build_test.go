@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/pflag"
+)
+
+// setupBuild resets the build flags to their defaults, then parses args
+// after placeholder --relatedness and --output and sets up from them
+func setupBuild(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := buildCmd.Flags()
+	flags.VisitAll(func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			s.Replace(nil)
+		} else if err := f.Value.Set(f.DefValue); err != nil {
+			t.Fatalf("Could not reset --%s: %s", f.Name, err)
+		}
+		f.Changed = false
+	})
+	args = append([]string{"--relatedness=in.csv", "--output=out.dot", "--input-format=three-column"}, args...)
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("Could not parse %v: %s", args, err)
+	}
+	setup(flags)
+	return flags
+}
+
+// readRelatedness reads contents as three-column relatedness input
+func readRelatedness(t *testing.T, contents string) relatedness.CsvInput {
+	t.Helper()
+	f, err := ioutil.TempFile("", "relped-*.csv")
+	if err != nil {
+		t.Fatalf("Could not create temporary file: %s", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	f.WriteString(contents)
+	f.Seek(0, 0)
+	return relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+}
+
+// exitCode runs f, returning the code it exits with, or -1 if it returns
+func exitCode(t *testing.T, f func()) (code int) {
+	t.Helper()
+	type exited struct{ code int }
+	logger := log.StandardLogger()
+	defer func(exit func(int)) { logger.ExitFunc = exit }(logger.ExitFunc)
+	logger.ExitFunc = func(code int) { panic(exited{code}) }
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(exited)
+			if !ok {
+				panic(r)
+			}
+			code = e.code
+		}
+	}()
+	f()
+	return -1
+}
+
+func TestBuild(t *testing.T) {
+	defer setupBuild(t)
+	t.Run("Pairs beyond the furthest distance are reported", func(t *testing.T) {
+		input := readRelatedness(t, "ID1,ID2,Rel\n"+
+			"I1,I2,0.5\n"+
+			"I2,I3,0.25\n"+
+			"I3,I4,0.125\n")
+		defer log.SetLevel(log.GetLevel())
+		log.SetLevel(log.DebugLevel)
+		hook := test.NewGlobal()
+		defer hook.Reset()
+
+		setupBuild(t, "--max-distance=1")
+		reportDistances(input)
+		warned := false
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == log.WarnLevel && strings.Contains(entry.Message, "2 of 3 related pairs") {
+				warned = true
+			}
+		}
+		if !warned {
+			t.Errorf("Expected a warning that 2 of 3 related pairs are excluded")
+		}
+
+		hook.Reset()
+		setupBuild(t, "--max-distance=5")
+		reportDistances(input)
+		higher := false
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == log.WarnLevel {
+				t.Errorf("Got warning %q, Expected none", entry.Message)
+			}
+			if strings.Contains(entry.Message, "higher than needed, no pair is further than 3") {
+				higher = true
+			}
+		}
+		if !higher {
+			t.Errorf("Expected a note that --max-distance 5 is higher than needed")
+		}
+	})
+	t.Run("Summaries count the run", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "relped-")
+		if err != nil {
+			t.Fatalf("Could not create temporary directory: %s", err)
+		}
+		defer os.RemoveAll(dir)
+		input := readRelatedness(t, "ID1,ID2,Rel\n"+
+			"I1,I2,0.5\n"+
+			"I2,I3,0.25\n"+
+			"I3,I4,0.125\n")
+		g := graph.NewGraphFromEdges([]graph.Edge{
+			{From: "I1", To: "I2", Relatedness: 0.5},
+			{From: "I2", To: "I3", Relatedness: 0.25},
+		}, graph.Options{MergeReciprocal: true})
+		flags := setupBuild(t, "--max-distance=2")
+		name := filepath.Join(dir, "summary.json")
+		writeSummary(name, flags, input, g, time.Now())
+
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("Could not open summary: %s", err)
+		}
+		defer f.Close()
+		var got summary
+		if err := json.NewDecoder(f).Decode(&got); err != nil {
+			t.Fatalf("Could not read summary: %s", err)
+		}
+		exp := summary{Pairs: 3, PairsKept: 2, PairsDropped: 1, Knowns: 3, Unknowns: 1, Edges: 3, Components: 1}
+		if got.Pairs != exp.Pairs || got.PairsKept != exp.PairsKept || got.PairsDropped != exp.PairsDropped {
+			t.Errorf("Got %d pairs with %d kept and %d dropped, Expected %d with %d and %d",
+				got.Pairs, got.PairsKept, got.PairsDropped, exp.Pairs, exp.PairsKept, exp.PairsDropped)
+		}
+		if got.Knowns != exp.Knowns || got.Unknowns != exp.Unknowns || got.Edges != exp.Edges || got.Components != exp.Components {
+			t.Errorf("Got %d knowns, %d unknowns, %d edges, and %d components, Expected %d, %d, %d, and %d",
+				got.Knowns, got.Unknowns, got.Edges, got.Components, exp.Knowns, exp.Unknowns, exp.Edges, exp.Components)
+		}
+		if got.Options["max-distance"] != "2" {
+			t.Errorf("Got options %v, Expected max-distance 2", got.Options)
+		}
+	})
+	t.Run("Rendering without Graphviz leaves the pedigree written", func(t *testing.T) {
+		defer os.Setenv("PATH", os.Getenv("PATH"))
+		os.Setenv("PATH", "")
+		hook := test.NewGlobal()
+		defer hook.Reset()
+		if code := exitCode(t, func() { render("out.dot", "out.svg", "") }); code != exit.IO {
+			t.Errorf("Got exit code %d, Expected %d", code, exit.IO)
+		}
+		if entry := hook.LastEntry(); entry == nil || !strings.Contains(entry.Message, "still written to out.dot") {
+			t.Errorf("Got %v, Expected a note that the pedigree was still written", entry)
+		}
+	})
+	t.Run("Rendering requires DOT output", func(t *testing.T) {
+		code := exitCode(t, func() { setupBuild(t, "--also-render=out.svg", "--format=gexf") })
+		if code != exit.Usage {
+			t.Errorf("Got exit code %d, Expected %d", code, exit.Usage)
+		}
+	})
+	t.Run("ML-Relate input defaults to third degree relationships", func(t *testing.T) {
+		setupBuild(t, "--input-format=ml-relate")
+		if opMaxDistance != mlRelateMaxDistance {
+			t.Errorf("Got --max-distance %d, Expected %d", opMaxDistance, mlRelateMaxDistance)
+		}
+		setupBuild(t, "--input-format=ml-relate", "--max-distance=2")
+		if opMaxDistance != 2 {
+			t.Errorf("Got --max-distance %d, Expected the given 2", opMaxDistance)
+		}
+		code := exitCode(t, func() { setupBuild(t, "--input-format=ml-relate", "--max-distance=5") })
+		if code != exit.Usage {
+			t.Errorf("Got exit code %d, Expected %d", code, exit.Usage)
+		}
+	})
+	t.Run("Distance statistics ignore disconnected pairs", func(t *testing.T) {
+		g := graph.NewGraphFromEdges([]graph.Edge{
+			{From: "I1", To: "I2", Relatedness: 0.5},
+			{From: "I2", To: "I3", Relatedness: 0.25},
+			{From: "I4", To: "I5", Relatedness: 0.5},
+		}, graph.Options{MergeReciprocal: true})
+		got := newDistanceStats(g)
+		if got.Pairs != 4 || got.Min != 1 || got.Max != 3 || got.Mean != 1.75 {
+			t.Errorf("Got %d pairs from %d to %d with mean %v, Expected 4 from 1 to 3 with mean 1.75",
+				got.Pairs, got.Min, got.Max, got.Mean)
+		}
+		exp := map[uint]int{1: 2, 2: 1, 3: 1}
+		if len(got.Histogram) != len(exp) {
+			t.Fatalf("Got histogram %v, Expected %v", got.Histogram, exp)
+		}
+		for d, n := range exp {
+			if got.Histogram[d] != n {
+				t.Errorf("Got histogram %v, Expected %v", got.Histogram, exp)
+				break
+			}
+		}
+	})
+	t.Run("Several formats replace the output extension", func(t *testing.T) {
+		setupBuild(t, "--output=out/pedigree.txt", "--formats=dot,matrix")
+		for format, exp := range map[string]string{
+			"dot":    "out/pedigree.dot",
+			"matrix": "out/pedigree.csv",
+			"gexf":   "",
+		} {
+			if got := outputName(format); got != exp {
+				t.Errorf("Got %q for %s, Expected %q", got, format, exp)
+			}
+		}
+		setupBuild(t, "--output=out/pedigree.txt")
+		if got := outputName("dot"); got != "out/pedigree.txt" {
+			t.Errorf("Got %q, Expected --output itself", got)
+		}
+		code := exitCode(t, func() { setupBuild(t, "--format=dot", "--formats=dot,gexf") })
+		if code != exit.Usage {
+			t.Errorf("Got exit code %d, Expected %d", code, exit.Usage)
+		}
+	})
+	t.Run("Presets set the furthest distance unless it is given", func(t *testing.T) {
+		for _, name := range presetNames {
+			setupBuild(t, "--preset="+name)
+			if opMaxDistance != presets[name] {
+				t.Errorf("Got --max-distance %d for %s, Expected %d", opMaxDistance, name, presets[name])
+			}
+		}
+		setupBuild(t, "--preset=close", "--max-distance=4")
+		if opMaxDistance != 4 {
+			t.Errorf("Got --max-distance %d, Expected the given 4", opMaxDistance)
+		}
+		code := exitCode(t, func() { setupBuild(t, "--preset=distant") })
+		if code != exit.Usage {
+			t.Errorf("Got exit code %d, Expected %d", code, exit.Usage)
+		}
+	})
+	t.Run("Dropped individuals are given a reason", func(t *testing.T) {
+		setupBuild(t, "--max-distance=3")
+		input := readRelatedness(t, "ID1,ID2,Rel\n"+
+			"I1,I2,0.5\n"+
+			"I2,I3,0.5\n"+
+			"I4,I5,0.0078125\n"+
+			"I6,I7,0.5\n"+
+			"I1,I8,0\n")
+		g := graph.NewGraphFromEdges([]graph.Edge{
+			{From: "I1", To: "I2", Relatedness: 0.5},
+			{From: "I2", To: "I3", Relatedness: 0.5},
+		}, graph.Options{MergeReciprocal: true})
+		kept, dropped := partitionIndividuals(input, g, []string{"I8", "I7", "I6", "I5", "I4", "I3", "I2", "I1"})
+		if got := strings.Join(kept, ","); got != "I1,I2,I3" {
+			t.Errorf("Got kept %s, Expected I1,I2,I3", got)
+		}
+		exp := map[string]string{
+			"I4": dropBelowThreshold,
+			"I5": dropBelowThreshold,
+			"I6": dropDisconnected,
+			"I7": dropDisconnected,
+			"I8": dropUnrelated,
+		}
+		if len(dropped) != len(exp) {
+			t.Errorf("Got dropped %v, Expected %v", dropped, exp)
+		}
+		for indv, reason := range exp {
+			if dropped[indv] != reason {
+				t.Errorf("Got %q for %s, Expected %q", dropped[indv], indv, reason)
+			}
+		}
+	})
+}
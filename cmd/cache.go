@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/version"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+)
+
+// outputFlags change only what is written, not the pruned pedigree,
+// so they do not invalidate a cache
+var outputFlags = map[string]bool{
+	"output": true, "format": true, "formats": true, "also-render": true, "save-layout": true, "output-summary": true,
+	"output-individuals": true, "output-dropped": true,
+	"inbreeding": true, "report-ambiguous": true, "report-unknowns": true, "report-detours": true, "impute-missing": true, "unmapped": true, "explain": true,
+	"precision": true, "layout-engine": true, "simple-layout": true, "rm-arrows": true,
+	"cluster-by": true, "rank-by": true, "infer-direction-from": true, "compact": true, "color-by": true, "legend": true, "edge-color-by-distance": true, "edge-width-by-support": true, "edge-tooltip": true, "generation-gap-years": true, "relationship-labels": true, "relationship-labels-from": true, "keep-self-loops-as-inbreeding": true,
+	"validate": true, "verbose": true, "mkdir": true, "cache-dir": true, "resume": true,
+	"timeout": true, "threads": true,
+}
+
+// cachePath names the cache in dir by a hash of the input files,
+// the options in flags that change the pedigree, and the relped version
+func cachePath(dir string, flags *pflag.FlagSet) string {
+	h := sha256.New()
+	fmt.Fprintln(h, version.GitTag)
+	for _, name := range []string{fRelatedness, fParentage, fDemographics} {
+		if name == "" {
+			fmt.Fprintln(h, "-")
+			continue
+		}
+		contents, err := ioutil.ReadFile(name)
+		if err != nil {
+			exit.Fatalf(exit.IO, "Could not read %s to check the cache: %s\n", name, err)
+		}
+		h.Write(contents)
+		fmt.Fprintln(h)
+	}
+	var opts []string
+	flags.Visit(func(f *pflag.Flag) {
+		if !outputFlags[f.Name] {
+			opts = append(opts, f.Name+"="+f.Value.String())
+		}
+	})
+	sort.Strings(opts)
+	for _, opt := range opts {
+		fmt.Fprintln(h, opt)
+	}
+	return filepath.Join(dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+// loadCache restores the graph saved in name,
+// returning nil when there is no usable cache
+func loadCache(name string) *graph.Graph {
+	f, err := os.Open(name)
+	if err != nil {
+		log.Infof("No cached pedigree for these inputs and options, building it\n")
+		return nil
+	}
+	defer f.Close()
+	g, err := graph.ReadCache(f)
+	if err != nil {
+		log.Warnf("Could not read cached pedigree, building it: %s\n", err)
+		return nil
+	}
+	log.Debugf("Resumed from cached pedigree: %s\n", name)
+	return g
+}
+
+// saveCache writes g to name, warning rather than failing
+// as the cache is only an aid to later runs
+func saveCache(name string, g *graph.Graph) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		log.Warnf("Could not create cache directory: %s\n", err)
+		return
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		log.Warnf("Could not create cache: %s\n", err)
+		return
+	}
+	defer f.Close()
+	if err := g.WriteCache(f); err != nil {
+		log.Warnf("Could not write cache: %s\n", err)
+	}
+}
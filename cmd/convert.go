@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/spf13/cobra"
+)
+
+// Convert flags
+var (
+	fConvertMLRelate string
+	fConvertOut      string
+)
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert ML-Relate output to three-column relatedness",
+	Long: `Read the output of ML-Relate and write the equivalent
+three-column relatedness file, using the Relatedness column,
+so it may be reused as --relatedness input.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		convert()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVar(&fConvertMLRelate, "ml-relate", "", "ML-Relate output file (required)")
+	convertCmd.MarkFlagRequired("ml-relate")
+	convertCmd.Flags().StringVar(&fConvertOut, "output", "", "Output three-column relatedness file (required)")
+	convertCmd.MarkFlagRequired("output")
+}
+
+func convert() {
+	in, err := os.Open(fConvertMLRelate)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not read input file: %s\n", err)
+	}
+	defer in.Close()
+	input := relatedness.NewMLRelateCsv(in, relatedness.Options{})
+
+	out, err := os.Create(fConvertOut)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create output file: %s\n", err)
+	}
+	defer out.Close()
+
+	if err := relatedness.WriteThreeColumnCsv(out, input); err != nil {
+		exit.Fatalf(exit.IO, "Could not write output file: %s\n", err)
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/pedigree"
+	"github.com/spf13/cobra"
+)
+
+// Diff flags
+var (
+	fDiffFrom   string
+	fDiffTo     string
+	fDiffOut    string
+	fDiffOutDot string
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the relationships in two pedigrees",
+	Long: `Read two pedigrees written by build, either as Graphviz (dot)
+or as saved by --cache-dir (json), and report the pairs of known
+individuals related in only one of them or at a different distance.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		diff()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&fDiffFrom, "from", "", "First pedigree (required)")
+	diffCmd.MarkFlagRequired("from")
+	diffCmd.Flags().StringVar(&fDiffTo, "to", "", "Second pedigree (required)")
+	diffCmd.MarkFlagRequired("to")
+	diffCmd.Flags().StringVar(&fDiffOut, "output", "", "File of pairs that differ as ID1,ID2,From,To,Change")
+	diffCmd.Flags().StringVar(&fDiffOutDot, "output-dot", "", "Graphviz file drawing pairs that differ: added in green, removed in red, changed in orange")
+}
+
+func diff() {
+	from, to := readPedigree(fDiffFrom), readPedigree(fDiffTo)
+	changes, same := graph.Diff(from, to)
+
+	added, removed := 0, 0
+	for _, c := range changes {
+		switch {
+		case c.From == 0:
+			added++
+		case c.To == 0:
+			removed++
+		}
+	}
+	fmt.Printf("%d related pairs added, %d removed, %d at a different distance, %d unchanged\n", added, removed, len(changes)-added-removed, same)
+
+	if fDiffOut != "" {
+		writeChanges(fDiffOut, changes)
+	}
+	if fDiffOutDot != "" {
+		if err := ioutil.WriteFile(fDiffOutDot, []byte(pedigree.Diff(changes)), 0644); err != nil {
+			exit.Fatalf(exit.IO, "Could not write differences: %s\n", err)
+		}
+	}
+}
+
+// readPedigree reads a pedigree as Graphviz, or as saved by --cache-dir
+// when name ends in .json
+func readPedigree(name string) *graph.Graph {
+	f, err := os.Open(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not read pedigree: %s\n", err)
+	}
+	defer f.Close()
+	var g *graph.Graph
+	if filepath.Ext(name) == ".json" {
+		g, err = graph.ReadCache(f)
+	} else {
+		g, err = pedigree.ReadGraph(f)
+	}
+	if err != nil {
+		exit.Fatalf(exit.Parse, "Could not read pedigree %s: %s\n", name, err)
+	}
+	return g
+}
+
+// writeChanges writes changes as a CSV, with NA for unrelated pairs
+func writeChanges(name string, changes []graph.Change) {
+	f, err := os.Create(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create differences file: %s\n", err)
+	}
+	defer f.Close()
+	dist := func(d uint) string {
+		if d == 0 {
+			return "NA"
+		}
+		return strconv.FormatUint(uint64(d), 10)
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"ID1", "ID2", "From", "To", "Change"})
+	for _, c := range changes {
+		change := "closer"
+		switch {
+		case c.From == 0:
+			change = "added"
+		case c.To == 0:
+			change = "removed"
+		case c.From < c.To:
+			change = "further"
+		}
+		w.Write([]string{c.ID1, c.ID2, dist(c.From), dist(c.To), change})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		exit.Fatalf(exit.IO, "Could not write differences file: %s\n", err)
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"sort"
+	"strings"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+// Reasons an individual in the input is missing from the pedigree
+const (
+	dropUnrelated      = "unrelated"
+	dropBelowThreshold = "below threshold"
+	dropDisconnected   = "disconnected"
+)
+
+// partitionIndividuals splits indvs into those in g and those dropped,
+// giving the reason each was dropped
+func partitionIndividuals(input relatedness.CsvInput, g *graph.Graph, indvs []string) (kept []string, dropped map[string]string) {
+	dropped = make(map[string]string)
+	for _, indv := range indvs {
+		if g.NodeNamed(indv) != nil {
+			kept = append(kept, indv)
+		} else {
+			dropped[indv] = dropUnrelated
+		}
+	}
+	sort.Strings(kept)
+	for _, pair := range input.Pairs() {
+		degree := input.RelDistance(pair[0], pair[1])
+		if degree == relational.Unrelated {
+			continue
+		}
+		reason := dropBelowThreshold
+		if minDist <= degree && degree <= relational.Degree(opMaxDistance) {
+			reason = dropDisconnected
+		}
+		for _, indv := range pair {
+			if dropped[indv] == dropUnrelated || (dropped[indv] == dropBelowThreshold && reason == dropDisconnected) {
+				dropped[indv] = reason
+			}
+		}
+	}
+	return kept, dropped
+}
+
+// writeIndividuals writes the individuals kept in g, one per line, and
+// those dropped as a CSV with the reason for each, to whichever is named
+func writeIndividuals(keptName, droppedName string, input relatedness.CsvInput, g *graph.Graph, indvs []string) {
+	kept, dropped := partitionIndividuals(input, g, indvs)
+	if keptName != "" {
+		f, err := createOutput(keptName)
+		if err != nil {
+			exit.Fatalf(exit.IO, "Could not create individuals file: %s\n", err)
+		}
+		defer f.Close()
+		if 0 < len(kept) {
+			kept = append(kept, "")
+		}
+		if _, err := f.WriteString(strings.Join(kept, "\n")); err != nil {
+			exit.Fatalf(exit.IO, "Could not write individuals file: %s\n", err)
+		}
+	}
+	if droppedName != "" {
+		f, err := createOutput(droppedName)
+		if err != nil {
+			exit.Fatalf(exit.IO, "Could not create dropped individuals file: %s\n", err)
+		}
+		defer f.Close()
+		ids := make([]string, 0, len(dropped))
+		for id := range dropped {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		w := csv.NewWriter(f)
+		w.Write([]string{"ID", "Reason"})
+		for _, id := range ids {
+			w.Write([]string{id, dropped[id]})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			exit.Fatalf(exit.IO, "Could not write dropped individuals file: %s\n", err)
+		}
+	}
+}
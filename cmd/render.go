@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/pedigree"
+)
+
+// render draws the DOT file as image with Graphviz, in the format
+// given by the extension of image (e.g. .svg or .png)
+func render(dot, image, engine string) {
+	bin, err := exec.LookPath("dot")
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not render %s, Graphviz dot was not found: %s\nThe pedigree was still written to %s\n", image, err, dot)
+	}
+	format := strings.TrimPrefix(filepath.Ext(image), ".")
+	if format == "" {
+		exit.Fatalf(exit.Usage, "Could not render %s, add an extension for the image format (e.g. .svg)\n", image)
+	}
+	args := []string{"-T" + format, "-o", image}
+	if engine != "" {
+		args = append(args, "-K"+engine)
+	}
+	args = append(args, dot)
+	if msg, err := exec.Command(bin, args...).CombinedOutput(); err != nil {
+		exit.Fatalf(exit.IO, "Could not render %s: %s\n%s", image, err, msg)
+	}
+}
+
+// saveLayout writes where Graphviz places each individual of the DOT file
+// to name as GraphML, so the same positions can be reused
+func saveLayout(dot, name, engine string) {
+	bin, err := exec.LookPath("dot")
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not save layout to %s, Graphviz dot was not found: %s\nThe pedigree was still written to %s\n", name, err, dot)
+	}
+	args := []string{"-Tplain"}
+	if engine != "" {
+		args = append(args, "-K"+engine)
+	}
+	args = append(args, dot)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(bin, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		exit.Fatalf(exit.IO, "Could not lay out %s: %s\n%s", dot, err, stderr.String())
+	}
+	layout, err := pedigree.ReadLayout(&stdout)
+	if err != nil {
+		exit.Fatalf(exit.Parse, "Could not read layout from Graphviz: %s\n", err)
+	}
+	f, err := createOutput(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create layout file: %s\n", err)
+	}
+	defer f.Close()
+	if err := layout.WriteGraphML(f); err != nil {
+		exit.Fatalf(exit.IO, "Could not write layout file: %s\n", err)
+	}
+}
@@ -4,14 +4,27 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/rhagenson/relped/internal/exit"
 	"github.com/rhagenson/relped/internal/version"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var opVerbose bool
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:     "relped",
 	Version: version.GitTag,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if opVerbose {
+			log.SetLevel(log.DebugLevel)
+		}
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&opVerbose, "verbose", false, "Report additional information while running")
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -19,6 +32,6 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exit.Usage)
 	}
 }
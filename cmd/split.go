@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit/relational"
+	log "github.com/sirupsen/logrus"
+)
+
+// partitionLabel names the partition of individuals without --split-by
+const partitionLabel = "NA"
+
+// partitionName is the file of partition label written in place of name,
+// e.g. pedigree-North.dot for pedigree.dot
+func partitionName(name, label string) string {
+	label = strings.NewReplacer("/", "_", "\\", "_", " ", "_").Replace(label)
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + label + ext
+}
+
+// writePartitions writes the pedigree of each --split-by partition of g
+// in each output format, next to --output
+func writePartitions(g *graph.Graph, inbred map[string]float64) {
+	parts := g.Partitions(opSplitBy)
+	vals := make([]string, 0, len(parts))
+	for val := range parts {
+		vals = append(vals, val)
+	}
+	sort.Strings(vals)
+	for _, val := range vals {
+		label := val
+		if label == "" {
+			label = partitionLabel
+		}
+		sub := g.Subgraph(parts[val])
+		ped, _ := newPedigree(sub, parts[val], inbred)
+		outs := make(map[string]*os.File)
+		for _, format := range outputFormats() {
+			out, err := createOutput(partitionName(outputName(format), label))
+			if err != nil {
+				exit.Fatalf(exit.IO, "Could not create output file: %s\n", err)
+			}
+			outs[format] = out
+		}
+		writeFormats(outs, sub, ped)
+		log.Infof("Wrote pedigree of %d individuals with %s %s\n", len(parts[val]), opSplitBy, label)
+	}
+}
+
+// reportCrossPartition reports the related pairs g leaves unlinked
+// for falling in different --split-by partitions
+func reportCrossPartition(input relatedness.CsvInput, g *graph.Graph) {
+	n := 0
+	for _, pair := range input.Pairs() {
+		degree := input.RelDistance(pair[0], pair[1])
+		if degree == relational.Unrelated || degree < minDist || relational.Degree(opMaxDistance) < degree {
+			continue
+		}
+		val1, _ := g.Info(pair[0]).Field(opSplitBy)
+		val2, _ := g.Info(pair[1]).Field(opSplitBy)
+		if val1 != val2 {
+			log.Debugf("%s (%s) and %s (%s) are at relational distance %d across --split-by %s\n", pair[0], val1, pair[1], val2, degree, opSplitBy)
+			n++
+		}
+	}
+	if n != 0 {
+		log.Infof("Excluded %d relationships between individuals with different %s\n", n, opSplitBy)
+	}
+}
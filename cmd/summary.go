@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
+	"github.com/rhagenson/relped/internal/version"
+	"github.com/spf13/pflag"
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// summary is a machine-readable report of a build
+type summary struct {
+	Input        string            `json:"input"`
+	Version      string            `json:"version"`
+	Options      map[string]string `json:"options"`
+	Pairs        int               `json:"pairs"`
+	PairsKept    int               `json:"pairs_kept"`
+	PairsDropped int               `json:"pairs_dropped"`
+	Knowns       int               `json:"known_nodes"`
+	Unknowns     int               `json:"unknown_nodes"`
+	Edges        int               `json:"edges"`
+	Components   int               `json:"components"`
+	Distances    *distanceStats    `json:"distances"`
+	Seconds      float64           `json:"seconds"`
+}
+
+// distanceStats describes the number of links between connected knowns
+type distanceStats struct {
+	Pairs     int          `json:"pairs"`
+	Min       uint         `json:"min"`
+	Max       uint         `json:"max"`
+	Mean      float64      `json:"mean"`
+	Histogram map[uint]int `json:"histogram"`
+}
+
+// newDistanceStats summarizes the distances between each pair of
+// connected knowns in g, ignoring pairs that are not connected
+func newDistanceStats(g *graph.Graph) *distanceStats {
+	stats := &distanceStats{Histogram: make(map[uint]int)}
+	_, dists := g.Distances()
+	total := uint(0)
+	for i := range dists {
+		for j := i + 1; j < len(dists); j++ {
+			d := dists[i][j]
+			if d == 0 {
+				continue
+			}
+			if stats.Pairs == 0 || d < stats.Min {
+				stats.Min = d
+			}
+			if stats.Max < d {
+				stats.Max = d
+			}
+			stats.Pairs++
+			total += d
+			stats.Histogram[d]++
+		}
+	}
+	if 0 < stats.Pairs {
+		stats.Mean = util.RoundFloat(float64(total)/float64(stats.Pairs), precision)
+	}
+	return stats
+}
+
+// writeSummary writes a JSON summary of building g from input
+// with the options set in flags
+func writeSummary(name string, flags *pflag.FlagSet, input relatedness.CsvInput, g *graph.Graph, start time.Time) {
+	s := summary{
+		Input:   fRelatedness,
+		Version: version.GitTag,
+		Options: make(map[string]string),
+	}
+	flags.Visit(func(f *pflag.Flag) {
+		s.Options[f.Name] = f.Value.String()
+	})
+
+	for _, pair := range input.Pairs() {
+		s.Pairs++
+		degree := input.RelDistance(pair[0], pair[1])
+		if degree != relational.Unrelated && minDist <= degree && degree <= relational.Degree(opMaxDistance) {
+			s.PairsKept++
+		} else {
+			s.PairsDropped++
+		}
+	}
+
+	nodes := g.Nodes()
+	for nodes.Next() {
+		if name, ok := g.IDToName(nodes.Node().ID()); ok && g.IsKnown(name) {
+			s.Knowns++
+		} else {
+			s.Unknowns++
+		}
+	}
+	s.Edges = g.Edges().Len()
+	s.Components = len(topo.ConnectedComponents(g))
+	s.Distances = newDistanceStats(g)
+	s.Seconds = util.RoundFloat(time.Since(start).Seconds(), precision)
+
+	f, err := createOutput(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create summary file: %s\n", err)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		exit.Fatalf(exit.IO, "Could not write summary file: %s\n", err)
+	}
+}
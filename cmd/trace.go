@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"sort"
+	"strconv"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/util"
+)
+
+// writeTrace writes, for each link in g, every relationship from the
+// input drawn through it: its relatedness as read, relational distance,
+// and the weight it gave the link
+// Links drawn from no relatedness, such as parentage, have a row without one
+func writeTrace(name string, g *graph.Graph) {
+	f, err := createOutput(name)
+	if err != nil {
+		exit.Fatalf(exit.IO, "Could not create trace file: %s\n", err)
+	}
+	defer f.Close()
+
+	var rows [][]string
+	edges := g.WeightedEdges()
+	for edges.Next() {
+		e := edges.WeightedEdge()
+		from, _ := g.IDToName(e.From().ID())
+		to, _ := g.IDToName(e.To().ID())
+		if to < from {
+			from, to = to, from
+		}
+		link := []string{from, to, util.FormatFloat(e.Weight(), precision)}
+		traces := g.Traces(from, to)
+		if len(traces) == 0 {
+			dist := ""
+			if d, ok := g.EdgeDistance(from, to); ok {
+				dist = strconv.Itoa(int(d))
+			}
+			rows = append(rows, append(link, "", "", "", dist, ""))
+			continue
+		}
+		for _, t := range traces {
+			rows = append(rows, append(link[:3:3],
+				t.ID1, t.ID2,
+				util.FormatFloat(float64(t.Relatedness), precision),
+				strconv.Itoa(int(t.Distance)),
+				util.FormatFloat(float64(t.Weight), precision),
+			))
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i][0] != rows[j][0] {
+			return rows[i][0] < rows[j][0]
+		}
+		return rows[i][1] < rows[j][1]
+	})
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"From", "To", "Weight", "ID1", "ID2", "Relatedness", "Distance", "LinkWeight"})
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		exit.Fatalf(exit.IO, "Could not write trace file: %s\n", err)
+	}
+}
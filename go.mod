@@ -3,7 +3,6 @@ module github.com/rhagenson/relped
 go 1.13
 
 require (
-	github.com/awalterschulze/gographviz v0.0.0-20190522210029-fa59802746ab
 	github.com/deckarep/golang-set v1.7.1
 	github.com/gocarina/gocsv v0.0.0-20191214001331-e6697589f2e0
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
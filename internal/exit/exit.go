@@ -0,0 +1,22 @@
+// Package exit names the codes relped exits with, so that scripts
+// can tell apart why it failed
+package exit
+
+import log "github.com/sirupsen/logrus"
+
+// Codes relped exits with
+const (
+	Usage      = 1 // Flags or arguments are invalid
+	IO         = 2 // A file could not be opened, read, or written
+	Timeout    = 3 // Pruning stopped at --timeout, the pedigree is partial
+	Parse      = 4 // Input could not be understood
+	Validation = 5 // Input or pedigree failed a check, such as under --strict
+)
+
+// Fatalf logs as log.Fatalf does, but exits with code
+// Exiting goes through the standard logger's ExitFunc, so tests can catch it
+func Fatalf(code int, format string, args ...interface{}) {
+	logger := log.StandardLogger()
+	logger.Logf(log.FatalLevel, format, args...)
+	logger.Exit(code)
+}
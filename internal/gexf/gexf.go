@@ -0,0 +1,159 @@
+// Package gexf writes a pedigree graph as GEXF for Gephi
+package gexf
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/util"
+)
+
+// Node attribute IDs, declared in order
+const (
+	attrKnown = iota
+	attrSex
+	attrAge
+	attrFamily
+)
+
+type document struct {
+	XMLName xml.Name `xml:"gexf"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Graph   gexfGraph
+}
+
+type gexfGraph struct {
+	XMLName     xml.Name   `xml:"graph"`
+	Mode        string     `xml:"mode,attr"`
+	DefaultEdge string     `xml:"defaultedgetype,attr"`
+	TimeFormat  string     `xml:"timeformat,attr,omitempty"`
+	Attributes  attributes `xml:"attributes"`
+	Nodes       []gexfNode `xml:"nodes>node"`
+	Edges       []gexfEdge `xml:"edges>edge"`
+}
+
+type attributes struct {
+	Class string      `xml:"class,attr"`
+	Attrs []attribute `xml:"attribute"`
+}
+
+type attribute struct {
+	ID    int    `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Start  string      `xml:"start,attr,omitempty"`
+	Values []attrValue `xml:"attvalues>attvalue"`
+}
+
+type attrValue struct {
+	For   int    `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfEdge struct {
+	ID     int    `xml:"id,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+	Weight string `xml:"weight,attr"`
+}
+
+// Write writes g as GEXF with each node marked known or unknown along with
+// any sex, age, and family, and each edge with its weight
+// When any age is known, nodes start in their birth year counted back
+// from year so Gephi can animate the pedigree by cohort
+func Write(w io.Writer, g *graph.Graph, year int, precision util.Precision) error {
+	doc := document{
+		Xmlns:   "http://www.gexf.net/1.2draft",
+		Version: "1.2",
+		Graph: gexfGraph{
+			Mode:        "static",
+			DefaultEdge: "undirected",
+			Attributes: attributes{
+				Class: "node",
+				Attrs: []attribute{
+					{ID: attrKnown, Title: "known", Type: "boolean"},
+					{ID: attrSex, Title: "sex", Type: "string"},
+					{ID: attrAge, Title: "age", Type: "integer"},
+					{ID: attrFamily, Title: "family", Type: "string"},
+				},
+			},
+		},
+	}
+
+	var names []string
+	idToName := make(map[int64]string)
+	nodes := g.Nodes()
+	for nodes.Next() {
+		if name, ok := g.IDToName(nodes.Node().ID()); ok {
+			names = append(names, name)
+			idToName[nodes.Node().ID()] = name
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		info := g.Info(name)
+		node := gexfNode{
+			ID:     name,
+			Label:  name,
+			Values: []attrValue{{For: attrKnown, Value: strconv.FormatBool(g.IsKnown(name))}},
+		}
+		if sex, ok := info.Field("sex"); ok {
+			node.Values = append(node.Values, attrValue{For: attrSex, Value: sex})
+		}
+		if age, ok := info.Field("age"); ok {
+			node.Values = append(node.Values, attrValue{For: attrAge, Value: age})
+			node.Start = strconv.Itoa(year - int(info.Age))
+			doc.Graph.Mode = "dynamic"
+			doc.Graph.TimeFormat = "double"
+		}
+		if family, ok := info.Field("family"); ok {
+			node.Values = append(node.Values, attrValue{For: attrFamily, Value: family})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+
+	edges := g.WeightedEdges()
+	for edges.Next() {
+		e := edges.WeightedEdge()
+		source, target := idToName[e.From().ID()], idToName[e.To().ID()]
+		if target < source {
+			source, target = target, source
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			Source: source,
+			Target: target,
+			Weight: util.FormatFloat(e.Weight(), precision),
+		})
+	}
+	sort.Slice(doc.Graph.Edges, func(i, j int) bool {
+		ei, ej := doc.Graph.Edges[i], doc.Graph.Edges[j]
+		if ei.Source != ej.Source {
+			return ei.Source < ej.Source
+		}
+		return ei.Target < ej.Target
+	})
+	for i := range doc.Graph.Edges {
+		doc.Graph.Edges[i].ID = i
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
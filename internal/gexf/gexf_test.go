@@ -0,0 +1,32 @@
+package gexf_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhagenson/relped/internal/gexf"
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/demographics"
+	"github.com/rhagenson/relped/internal/util"
+)
+
+func TestWrite(t *testing.T) {
+	g := graph.NewGraph([]string{"I1", "I2"})
+	g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 2))
+	g.AddAge("I1", demographics.Age(10))
+	out := new(strings.Builder)
+	if err := gexf.Write(out, g, 2020, util.Precision{}); err != nil {
+		t.Fatalf("Could not write GEXF: %s", err)
+	}
+	for _, str := range []string{
+		`mode="dynamic"`,
+		`<node id="I1" label="I1" start="2010">`,
+		`<node id="U1" label="U1">`,
+		`<attvalue for="0" value="false">`,
+		`<edge id="0" source="I1" target="U1" weight="2">`,
+	} {
+		if !strings.Contains(out.String(), str) {
+			t.Errorf("expected %s in:\n%s", str, out)
+		}
+	}
+}
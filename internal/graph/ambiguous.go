@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/graph/path"
+)
+
+// Ambiguous is a pair of knowns linked by more than one shortest path
+type Ambiguous struct {
+	ID1, ID2 string
+	Paths    int     // Number of equally short paths
+	Weight   float64 // Total weight of each path
+}
+
+// Ambiguous finds every pair of knowns linked by more than one path
+// of equal, shortest weight, whose relationship the pedigree cannot
+// resolve uniquely
+func (graph *Graph) Ambiguous() []Ambiguous {
+	indvs := make([]string, len(graph.knowns))
+	copy(indvs, graph.knowns)
+	sort.Strings(indvs)
+
+	var ambiguous []Ambiguous
+	all := path.DijkstraAllPaths(graph)
+	for i := range indvs {
+		src := graph.NodeNamed(indvs[i])
+		if src == nil {
+			continue
+		}
+		for j := i + 1; j < len(indvs); j++ {
+			if dest := graph.NodeNamed(indvs[j]); dest != nil {
+				if paths, weight := all.AllBetween(src.ID(), dest.ID()); 1 < len(paths) {
+					ambiguous = append(ambiguous, Ambiguous{
+						ID1:    indvs[i],
+						ID2:    indvs[j],
+						Paths:  len(paths),
+						Weight: weight,
+					})
+				}
+			}
+		}
+	}
+	return ambiguous
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/rhagenson/relped/internal/unit/relational"
+	gonumGraph "gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/network"
+	"gonum.org/v1/gonum/graph/path"
+)
+
+// PruneBetweenness is an alternative to Prune, keeping the backbone of
+// links most central to the graph. Links are kept in order of their edge
+// betweenness over weighted shortest paths, skipping any that would close
+// a loop, so every pair of individuals connected before stays connected
+// through the most travelled links. Unknowns left on dead ends are then
+// removed. Direct first degree links between knowns, the most confident
+// relationships, are always kept. Otherwise the result is a tree within
+// each component, so loops, such as full siblings sharing both parents,
+// are drawn through one parent only.
+func (graph *Graph) PruneBetweenness() {
+	centrality := network.EdgeBetweennessWeighted(graph, path.DijkstraAllPaths(graph))
+
+	type link struct {
+		ids    [2]int64
+		names  [2]string
+		direct bool
+		score  float64
+		weight float64
+	}
+	var links []link
+	edges := graph.WeightedEdges()
+	for edges.Next() {
+		e := edges.WeightedEdge()
+		x, y := e.From().ID(), e.To().ID()
+		if y < x {
+			x, y = y, x
+		}
+		n1, _ := graph.IDToName(x)
+		n2, _ := graph.IDToName(y)
+		if n2 < n1 {
+			n1, n2 = n2, n1
+		}
+		direct := graph.edgeDists[[2]int64{x, y}] == relational.First && graph.IsKnown(n1) && graph.IsKnown(n2)
+		links = append(links, link{[2]int64{x, y}, [2]string{n1, n2}, direct, centrality[[2]int64{x, y}], e.Weight()})
+	}
+	sort.Slice(links, func(i, j int) bool {
+		switch {
+		case links[i].direct != links[j].direct:
+			return links[i].direct
+		case links[i].score != links[j].score:
+			return links[i].score > links[j].score
+		case links[i].weight != links[j].weight:
+			return links[i].weight < links[j].weight
+		case links[i].names[0] != links[j].names[0]:
+			return links[i].names[0] < links[j].names[0]
+		default:
+			return links[i].names[1] < links[j].names[1]
+		}
+	})
+
+	// Join components from the most central link down, as Kruskal's
+	// algorithm does from the lightest
+	parent := make(map[int64]int64)
+	var find func(id int64) int64
+	find = func(id int64) int64 {
+		if p, ok := parent[id]; ok && p != id {
+			root := find(p)
+			parent[id] = root
+			return root
+		}
+		return id
+	}
+	for _, l := range links {
+		if r1, r2 := find(l.ids[0]), find(l.ids[1]); r1 != r2 {
+			parent[r1] = r2
+		} else if !l.direct {
+			graph.RemoveEdge(l.ids[0], l.ids[1])
+		}
+	}
+	graph.RmIsolatedUnknowns()
+
+	graph.support = make(map[[2]int64]int)
+	graph.eachKnownPath(func(_, _ string, nodes []gonumGraph.Node, _ float64) {
+		graph.addSupport(nodes)
+	})
+}
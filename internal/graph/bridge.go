@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// BridgeBy joins components holding knowns that share a value of the
+// named information, such as family, by an unknown ancestor linked to
+// the first known by name with that value in each component. Bridges
+// are assumptions rather than relatedness, so are marked to be told
+// apart, and carry no relational distance. Unknowns are named starting
+// with prefix. Returns the number of bridging unknowns added.
+func (graph *Graph) BridgeBy(field, prefix string) int {
+	// First known with each value in each component
+	firsts := make(map[string]map[int]string)
+	for i, component := range topo.ConnectedComponents(graph) {
+		for _, node := range component {
+			name, ok := graph.IDToName(node.ID())
+			if !ok || !graph.IsKnown(name) {
+				continue
+			}
+			val, ok := graph.Info(name).Field(field)
+			if !ok {
+				continue
+			}
+			if firsts[val] == nil {
+				firsts[val] = make(map[int]string)
+			}
+			if first, ok := firsts[val][i]; !ok || name < first {
+				firsts[val][i] = name
+			}
+		}
+	}
+
+	vals := make([]string, 0, len(firsts))
+	for val := range firsts {
+		vals = append(vals, val)
+	}
+	sort.Strings(vals)
+	newName := graph.unknownNamer(prefix)
+	bridges := 0
+	for _, val := range vals {
+		if len(firsts[val]) < 2 {
+			continue
+		}
+		names := make([]string, 0, len(firsts[val]))
+		for _, name := range firsts[val] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		ancestor := newName()
+		graph.AddNodeNamed(ancestor)
+		for _, name := range names {
+			graph.SetWeightedEdge(graph.NewWeightedEdgeNamed(ancestor, name, 1))
+			x, _ := graph.NameToID(ancestor)
+			y, _ := graph.NameToID(name)
+			graph.bridge(x, y)
+		}
+		bridges++
+	}
+	return bridges
+}
+
+func (graph *Graph) bridge(x, y int64) {
+	if y < x {
+		x, y = y, x
+	}
+	graph.bridged[[2]int64{x, y}] = true
+}
+
+// IsBridged reports whether the link between n1 and n2 was added by
+// BridgeBy rather than drawn from relatedness
+func (graph *Graph) IsBridged(n1, n2 string) bool {
+	x, xOk := graph.NameToID(n1)
+	y, yOk := graph.NameToID(n2)
+	if !xOk || !yOk {
+		return false
+	}
+	if y < x {
+		x, y = y, x
+	}
+	return graph.bridged[[2]int64{x, y}]
+}
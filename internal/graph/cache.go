@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/rhagenson/relped/internal/unit/relational"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// cache is the on-disk form of a Graph
+type cache struct {
+	Knowns []string
+	Nodes  map[string]Info
+	Edges  []cacheEdge
+}
+
+type cacheEdge struct {
+	From, To   string
+	Weight     float64
+	Distance   relational.Degree `json:",omitempty"`
+	Summarized bool              `json:",omitempty"`
+	Span       relational.Degree `json:",omitempty"`
+	Carried    []string          `json:",omitempty"`
+	Support    int               `json:",omitempty"`
+	Bridged    bool              `json:",omitempty"`
+	Traces     []Trace           `json:",omitempty"`
+}
+
+// WriteCache saves the graph to be restored with ReadCache
+func (graph *Graph) WriteCache(w io.Writer) error {
+	c := cache{
+		Knowns: graph.knowns,
+		Nodes:  make(map[string]Info),
+	}
+	idToName := make(map[int64]string)
+	for name, info := range graph.nameToInfo {
+		if graph.Node(info.ID) != nil {
+			c.Nodes[name] = info
+			idToName[info.ID] = name
+		}
+	}
+	edges := graph.WeightedEdges()
+	for edges.Next() {
+		e := edges.WeightedEdge()
+		from, to := idToName[e.From().ID()], idToName[e.To().ID()]
+		dist, _ := graph.EdgeDistance(from, to)
+		c.Edges = append(c.Edges, cacheEdge{
+			From:     from,
+			To:       to,
+			Weight:   e.Weight(),
+			Distance: dist,
+
+			Summarized: graph.IsSummarized(from, to),
+			Span:       graph.span(e.From().ID(), e.To().ID()),
+			Carried:    graph.Carried(from, to),
+			Support:    graph.Support(from, to),
+			Bridged:    graph.IsBridged(from, to),
+			Traces:     graph.Traces(from, to),
+		})
+	}
+	sort.Slice(c.Edges, func(i, j int) bool {
+		if c.Edges[i].From != c.Edges[j].From {
+			return c.Edges[i].From < c.Edges[j].From
+		}
+		return c.Edges[i].To < c.Edges[j].To
+	})
+	return json.NewEncoder(w).Encode(c)
+}
+
+// ReadCache restores a graph saved with WriteCache,
+// keeping the same node IDs
+func ReadCache(r io.Reader) (*Graph, error) {
+	var c cache
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	graph := NewGraph(c.Knowns)
+	for name, info := range c.Nodes {
+		graph.AddNode(simple.Node(info.ID))
+		graph.AddInfo(name, info)
+	}
+	for _, e := range c.Edges {
+		from, _ := graph.NameToID(e.From)
+		to, _ := graph.NameToID(e.To)
+		graph.SetWeightedEdge(graph.NewWeightedEdge(graph.Node(from), graph.Node(to), e.Weight))
+		if e.Distance != relational.Unrelated {
+			graph.tagDistance(from, to, e.Distance)
+		}
+		if e.Summarized {
+			graph.summarize(from, to, e.Span)
+		}
+		for _, val := range e.Carried {
+			graph.carry(from, to, val)
+		}
+		if e.Bridged {
+			graph.bridge(from, to)
+		}
+		for _, t := range e.Traces {
+			if graph.traces == nil {
+				graph.traces = make(map[[2]int64][]Trace)
+			}
+			graph.trace(from, to, t)
+		}
+		if e.Support != 0 {
+			if to < from {
+				graph.support[[2]int64{to, from}] = e.Support
+			} else {
+				graph.support[[2]int64{from, to}] = e.Support
+			}
+		}
+	}
+	return graph, nil
+}
@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+// Detour is a pair of knowns whose shortest path in the pedigree differs
+// from the relational distance their relatedness implies
+type Detour struct {
+	ID1, ID2 string
+	Direct   relational.Degree // Implied by relatedness
+	Drawn    uint              // Distance along the shortest path, zero if not connected
+}
+
+// Detours compares the relational distance of each pair of knowns in in,
+// from minDist to maxDist (Unrelated for no limit), with the distance
+// between them after pruning. Pairs that differ were either routed
+// through others or lost their own path while pruning.
+func (graph *Graph) Detours(in relatedness.CsvInput, minDist, maxDist relational.Degree) []Detour {
+	names, dists := graph.Distances()
+	var detours []Detour
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			direct := in.RelDistance(names[i], names[j])
+			if direct == relational.Unrelated {
+				direct = in.RelDistance(names[j], names[i])
+			}
+			if direct == relational.Unrelated || direct < minDist {
+				continue
+			}
+			if maxDist != relational.Unrelated && maxDist < direct {
+				continue
+			}
+			if dists[i][j] != uint(direct) {
+				detours = append(detours, Detour{ID1: names[i], ID2: names[j], Direct: direct, Drawn: dists[i][j]})
+			}
+		}
+	}
+	return detours
+}
@@ -0,0 +1,56 @@
+package graph
+
+import "sort"
+
+// Change is a pair of knowns whose distance differs between two graphs,
+// with zero distance where the pair is not connected
+type Change struct {
+	ID1, ID2 string
+	From, To uint
+}
+
+// Diff compares the distance between every pair of knowns in from and to,
+// returning the pairs that differ and the number of related pairs that
+// are the same. Knowns are matched by name, while unknowns, named anew
+// each run, are only compared by the paths they form.
+func Diff(from, to *Graph) (changes []Change, same int) {
+	fromDists, toDists := pairDistances(from), pairDistances(to)
+	pairs := make([][2]string, 0, len(fromDists))
+	for pair := range fromDists {
+		pairs = append(pairs, pair)
+	}
+	for pair := range toDists {
+		if _, ok := fromDists[pair]; !ok {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	for _, pair := range pairs {
+		if d1, d2 := fromDists[pair], toDists[pair]; d1 != d2 {
+			changes = append(changes, Change{ID1: pair[0], ID2: pair[1], From: d1, To: d2})
+		} else {
+			same++
+		}
+	}
+	return changes, same
+}
+
+// pairDistances are the distances of connected pairs of knowns,
+// keyed by their names in sorted order
+func pairDistances(graph *Graph) map[[2]string]uint {
+	names, dists := graph.Distances()
+	pairs := make(map[[2]string]uint)
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			if dists[i][j] != 0 {
+				pairs[[2]string{names[i], names[j]}] = dists[i][j]
+			}
+		}
+	}
+	return pairs
+}
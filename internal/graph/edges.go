@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"sort"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit"
+	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
+)
+
+// Edge is the relatedness of a pair of individuals, held in memory
+type Edge struct {
+	From, To    string
+	Relatedness float64
+}
+
+// NewGraphFromEdges builds a graph from relatedness held in memory, as
+// NewGraphFromCsvInput does from a relatedness file without parentage
+// or demographics. Relatedness is looked up in either direction, so
+// set opts.MergeReciprocal to link each pair once, and a pair given
+// more than once takes its last relatedness.
+func NewGraphFromEdges(edges []Edge, opts Options) *Graph {
+	return NewGraphFromCsvInput(newEdgeInput(edges, opts.Rounding), relational.Unrelated, nil, nil, opts)
+}
+
+var _ relatedness.CsvInput = new(edgeInput)
+
+// edgeInput is relatedness.CsvInput of edges
+type edgeInput struct {
+	indvs mapset.Set
+	pairs [][2]string
+	rels  map[[2]string]float64 // Keyed by names in order
+	mode  util.Rounding
+}
+
+func newEdgeInput(edges []Edge, mode util.Rounding) *edgeInput {
+	in := &edgeInput{
+		indvs: mapset.NewSet(),
+		rels:  make(map[[2]string]float64, len(edges)),
+		mode:  mode,
+	}
+	for _, e := range edges {
+		in.indvs.Add(e.From)
+		in.indvs.Add(e.To)
+		key := edgeKey(e.From, e.To)
+		if _, ok := in.rels[key]; !ok {
+			in.pairs = append(in.pairs, key)
+		}
+		in.rels[key] = e.Relatedness
+	}
+	sort.Slice(in.pairs, func(i, j int) bool {
+		if in.pairs[i][0] != in.pairs[j][0] {
+			return in.pairs[i][0] < in.pairs[j][0]
+		}
+		return in.pairs[i][1] < in.pairs[j][1]
+	})
+	return in
+}
+
+func edgeKey(i1, i2 string) [2]string {
+	if i2 < i1 {
+		return [2]string{i2, i1}
+	}
+	return [2]string{i1, i2}
+}
+
+func (in *edgeInput) Indvs() mapset.Set {
+	return in.indvs
+}
+
+func (in *edgeInput) Pairs() [][2]string {
+	return in.pairs
+}
+
+func (in *edgeInput) Relatedness(i1, i2 string) unit.Relatedness {
+	return unit.Relatedness(in.rels[edgeKey(i1, i2)])
+}
+
+func (in *edgeInput) RelDistance(i1, i2 string) relational.Degree {
+	return util.RelToLevel(in.rels[edgeKey(i1, i2)], in.mode)
+}
@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"gonum.org/v1/gonum/graph/path"
+)
+
+// Explanation is how two individuals are related in the graph
+type Explanation struct {
+	Names    []string  // Individuals along the path, starting at the first
+	Weights  []float64 // Weight of the edge between each consecutive pair of Names
+	Distance uint      // Relational distance along the path, zero through a bridge
+}
+
+// Explain finds the shortest path between two named individuals
+func (graph *Graph) Explain(from, to string) (Explanation, error) {
+	src := graph.NodeNamed(from)
+	if src == nil {
+		return Explanation{}, fmt.Errorf("%q is not in the pedigree", from)
+	}
+	dest := graph.NodeNamed(to)
+	if dest == nil {
+		return Explanation{}, fmt.Errorf("%q is not in the pedigree", to)
+	}
+	nodes, _ := path.DijkstraFrom(src, graph).To(dest.ID())
+	if len(nodes) < 2 {
+		return Explanation{}, fmt.Errorf("%q and %q are not connected in the pedigree", from, to)
+	}
+
+	exp := Explanation{
+		Names:   make([]string, len(nodes)),
+		Weights: make([]float64, len(nodes)-1),
+	}
+	exp.Distance, _ = graph.pathDistance(nodes)
+	for i, node := range nodes {
+		exp.Names[i], _ = graph.IDToName(node.ID())
+		if 0 < i {
+			exp.Weights[i-1], _ = graph.Weight(nodes[i-1].ID(), node.ID())
+		}
+	}
+	return exp, nil
+}
+
+// Describe writes the Explanation in human-readable form, marking unknowns
+func (exp Explanation) Describe(isKnown func(string) bool) string {
+	var total float64
+	for _, w := range exp.Weights {
+		total += w
+	}
+	s := new(strings.Builder)
+	if exp.Distance == 0 {
+		fmt.Fprintf(s, "%s and %s are related through an assumed bridge, at no known relational distance (total weight %.3f):\n",
+			exp.Names[0], exp.Names[len(exp.Names)-1], total)
+	} else {
+		fmt.Fprintf(s, "%s and %s are related at relational distance %d (total weight %.3f):\n",
+			exp.Names[0], exp.Names[len(exp.Names)-1], exp.Distance, total)
+	}
+	for i, name := range exp.Names {
+		if 0 < i {
+			fmt.Fprintf(s, "\t  | weight %.3f\n", exp.Weights[i-1])
+		}
+		if isKnown(name) {
+			fmt.Fprintf(s, "\t%s\n", name)
+		} else {
+			fmt.Fprintf(s, "\t%s (unknown)\n", name)
+		}
+	}
+	return s.String()
+}
@@ -0,0 +1,19 @@
+package graph
+
+import "gonum.org/v1/gonum/graph/path"
+
+// SearchKnowns makes the shortest path search from each known that
+// pruning makes, over edge weights cached beforehand or else looked up
+// as each edge is visited
+func SearchKnowns(graph *Graph, cached bool) {
+	if cached {
+		weighted := graph.cacheWeights()
+		for _, indv := range graph.knowns {
+			weighted.shortestFrom(graph.NodeNamed(indv))
+		}
+		return
+	}
+	for _, indv := range graph.knowns {
+		path.DijkstraFrom(graph.NodeNamed(indv), orderedGraph{graph})
+	}
+}
@@ -1,8 +1,14 @@
 package graph
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
 	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/rhagenson/relped/internal/io/demographics"
@@ -10,8 +16,8 @@ import (
 	"github.com/rhagenson/relped/internal/io/relatedness"
 	"github.com/rhagenson/relped/internal/unit"
 	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
 	gonumGraph "gonum.org/v1/gonum/graph"
-	"gonum.org/v1/gonum/graph/path"
 	"gonum.org/v1/gonum/graph/simple"
 	"gonum.org/v1/gonum/graph/topo"
 )
@@ -27,6 +33,17 @@ type Graph struct {
 	wug        *simple.WeightedUndirectedGraph
 	nameToInfo map[string]Info
 	knowns     []string
+	stableIDs  bool
+	threads    int
+	scaffold   map[int64]bool // Unknowns kept through pruning
+	edgeDists  map[[2]int64]relational.Degree
+	summarized map[[2]int64]relational.Degree // Distance spanned by links standing in for capped unknowns
+	carried    map[[2]int64][]string          // Input values of the relationships drawn through links
+	support    map[[2]int64]int               // Shortest paths between knowns through links, set by pruning
+	bridged    map[[2]int64]bool              // Links added by BridgeBy
+	traces     map[[2]int64][]Trace           // Relationships drawn through links, when tracing
+	rng        *rand.Rand                     // Names unknowns when seeded
+	rngNames   map[string]bool
 }
 
 type Info struct {
@@ -34,6 +51,39 @@ type Info struct {
 	Sex       demographics.Sex
 	Age       demographics.Age
 	Dam, Sire string
+	Family    string
+}
+
+// InfoFields are the names accepted by Info.Field
+var InfoFields = []string{"family", "sex", "age", "sire", "dam"}
+
+// IsInfoField reports whether name is one of InfoFields
+func IsInfoField(name string) bool {
+	for _, field := range InfoFields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns the named information as a string
+// Returns false if the information is not set
+func (info Info) Field(name string) (string, bool) {
+	switch name {
+	case "family":
+		return info.Family, info.Family != ""
+	case "sex":
+		return info.Sex.String(), info.Sex != demographics.Unknown
+	case "age":
+		return strconv.Itoa(int(info.Age)), info.Age != 0
+	case "sire":
+		return info.Sire, info.Sire != ""
+	case "dam":
+		return info.Dam, info.Dam != ""
+	default:
+		return "", false
+	}
 }
 
 func NewGraph(indvs []string) *Graph {
@@ -41,29 +91,140 @@ func NewGraph(indvs []string) *Graph {
 		wug:        simple.NewWeightedUndirectedGraph(math.MaxFloat64, math.MaxFloat64),
 		nameToInfo: make(map[string]Info, len(indvs)),
 		knowns:     indvs,
+		scaffold:   make(map[int64]bool),
+		edgeDists:  make(map[[2]int64]relational.Degree),
+		summarized: make(map[[2]int64]relational.Degree),
+		carried:    make(map[[2]int64][]string),
+		support:    make(map[[2]int64]int),
+		bridged:    make(map[[2]int64]bool),
 	}
 }
 
-func NewGraphFromCsvInput(in relatedness.CsvInput, minDist relational.Degree, pars parentage.CsvInput, dems demographics.CsvInput) *Graph {
+// Options alter how a Graph is built from input
+type Options struct {
+	Scheme      WeightScheme      // How relationship weight is spread across unknowns
+	IDDelimiter string            // Splits composite IDs into family and individual
+	StableIDs   bool              // Derive node IDs from names rather than insertion order
+	MaxDist     relational.Degree // Furthest relationship to include, Unrelated for no limit
+
+	// UnknownPrefix starts the name of every inferred unknown
+	// Unknowns are told apart from knowns by name, so any prefix is safe
+	UnknownPrefix string
+	// Threads is the number of knowns searched from at once while pruning
+	Threads int
+	// CategoryTopology links knowns by the shape of their relationship
+	// category, when known, rather than a chain of unknowns
+	CategoryTopology bool
+	// DistanceWeights weights relationships by DistanceWeight rather than
+	// inverse relatedness, so path costs are comparable across distances
+	DistanceWeights bool
+	// MergeReciprocal links each pair once, rather than once from each side
+	MergeReciprocal bool
+	// Seed names unknowns reproducibly, zero names them uniquely per run
+	Seed int64
+	// MaxUnknownChain caps the unknowns drawn for a relationship, with
+	// one summarizing link for the rest; zero draws every unknown
+	MaxUnknownChain int
+	// WeightByMarkers raises the weight of relationships estimated from
+	// fewer markers, by MarkerWeight, when the input counts markers
+	WeightByMarkers bool
+	// RelatednessPower raises relatedness to this power before weighting,
+	// so pruning more strongly prefers close relationships; zero is one
+	RelatednessPower float64
+	// SplitBy names the information field, one of InfoFields, that
+	// individuals must share to be linked; empty links any pair
+	SplitBy string
+	// Trace keeps the relationships drawn through each link, see Traces
+	Trace bool
+	// ShareUnknowns draws a relationship through an unknown parent already
+	// shared by an end and its siblings, when the other end is as related
+	// to every sibling, rather than through new unknowns
+	ShareUnknowns bool
+	// Rounding rounds relatedness falling between two distances, for
+	// relatedness not already given a distance by its input
+	Rounding util.Rounding
+}
+
+func NewGraphFromCsvInput(in relatedness.CsvInput, minDist relational.Degree, pars parentage.CsvInput, dems demographics.CsvInput, opts Options) *Graph {
 	indvs := in.Indvs()
 	strIndvs := make([]string, 0, indvs.Cardinality())
 	for _, indv := range indvs.ToSlice() {
 		strIndvs = append(strIndvs, indv.(string))
 	}
+	sort.Strings(strIndvs) // Link pairs in the same order every run
 	g := NewGraph(strIndvs)
+	if opts.Seed != 0 {
+		g.UseSeed(opts.Seed)
+	}
+	if opts.StableIDs {
+		g.UseStableIDs()
+	}
+	g.UseThreads(opts.Threads)
+	if opts.Trace {
+		g.traces = make(map[[2]int64][]Trace)
+	}
+	carry, _ := in.(relatedness.CarryInput)
+	markers, _ := in.(relatedness.MarkerInput)
+	if !opts.WeightByMarkers {
+		markers = nil
+	}
+	apart := func(from, to string) bool {
+		return opts.SplitBy != "" &&
+			splitValue(from, opts.SplitBy, pars, dems, opts.IDDelimiter) != splitValue(to, opts.SplitBy, pars, dems, opts.IDDelimiter)
+	}
 
 	// Add any unknowns to link knowns by relational distance
 	for i := range strIndvs {
 		for j := range strIndvs {
-			if i == j {
+			if i == j || (opts.MergeReciprocal && j < i) {
 				continue
 			} else {
 				from := strIndvs[i]
 				to := strIndvs[j]
 				degree := in.RelDistance(from, to)
 				relatedness := in.Relatedness(from, to)
+				input := relatedness
+				if degree == relational.Unrelated {
+					continue // Unrelated pairs have no path between them
+				}
+				if opts.MaxDist != relational.Unrelated && opts.MaxDist < degree {
+					continue
+				}
+				if apart(from, to) {
+					continue // Split individuals are drawn in separate pedigrees
+				}
 				if minDist <= degree {
-					if path, err := NewRelationalWeightPath(from, to, degree, relatedness.Weight()); err == nil {
+					if opts.RelatednessPower != 0 && opts.RelatednessPower != 1 {
+						relatedness = unit.Relatedness(math.Pow(float64(relatedness), opts.RelatednessPower))
+					}
+					weight := relatedness.Weight()
+					if opts.DistanceWeights {
+						weight = DistanceWeight(relatedness)
+					}
+					if markers != nil {
+						if n, ok := markers.Markers(from, to); ok {
+							weight = MarkerWeight(weight, n, markers.MaxMarkers())
+						}
+					}
+					var carried string
+					if carry != nil {
+						carried, _ = carry.Carried(from, to)
+					}
+					if opts.CategoryTopology && g.addCategoryScaffold(in, from, to, weight, carried, opts) {
+						continue
+					}
+					newName := g.unknownNamer(opts.UnknownPrefix)
+					if opts.ShareUnknowns && relational.Second <= degree {
+						first, _ := g.sharedParent(in, from, to, degree)
+						last, _ := g.sharedParent(in, to, from, degree)
+						if last == first {
+							last = ""
+						}
+						newName = sharingNamer(first, last, chainLinks(degree, opts.MaxUnknownChain)-1, newName)
+					}
+					if path, err := newRelationalWeightPath(from, to, degree, weight, opts.Scheme, opts.MaxUnknownChain, newName); err == nil {
+						path.carried = carried
+						path.input = input
 						g.AddPath(path)
 					}
 				}
@@ -82,12 +243,16 @@ func NewGraphFromCsvInput(in relatedness.CsvInput, minDist relational.Degree, pa
 				if sire, ok := pars.Sire(child); ok {
 					g.AddSire(child, sire)
 					g.AddNodeNamed(sire)
-					g.AddPath(NewEqualWeightPath([]string{sire, child}, relatedness.Weight()))
+					if !apart(sire, child) {
+						g.AddPath(NewEqualWeightPath([]string{sire, child}, relatedness.Weight()))
+					}
 				}
 				if dam, ok := pars.Dam(child); ok {
 					g.AddDam(child, dam)
 					g.AddNodeNamed(dam)
-					g.AddPath(NewEqualWeightPath([]string{dam, child}, relatedness.Weight()))
+					if !apart(dam, child) {
+						g.AddPath(NewEqualWeightPath([]string{dam, child}, relatedness.Weight()))
+					}
 				}
 			}
 		}
@@ -105,6 +270,15 @@ func NewGraphFromCsvInput(in relatedness.CsvInput, minDist relational.Degree, pa
 		}
 	}
 
+	// Add families from composite IDs
+	if opts.IDDelimiter != "" {
+		for _, indv := range strIndvs {
+			if family, _ := util.SplitID(indv, opts.IDDelimiter); family != "" {
+				g.AddFamily(indv, family)
+			}
+		}
+	}
+
 	return g
 }
 
@@ -118,6 +292,11 @@ func (graph *Graph) AddSex(name string, sex demographics.Sex) {
 	info.Sex = sex
 	graph.nameToInfo[name] = info
 }
+func (graph *Graph) AddFamily(name, family string) {
+	info := graph.nameToInfo[name]
+	info.Family = family
+	graph.nameToInfo[name] = info
+}
 func (graph *Graph) AddSire(name, sire string) {
 	info := graph.nameToInfo[name]
 	if sire != "" {
@@ -141,36 +320,95 @@ func (graph *Graph) AddInfo(name string, info Info) {
 	graph.nameToInfo[name] = info
 }
 
+// Prune removes all nodes not on the shortest path between two knowns
 func (graph *Graph) Prune() {
+	graph.PruneContext(context.Background())
+}
+
+// PruneContext is Prune, stopping early when ctx is done
+// Knowns not yet searched from when ctx is done are not connected,
+// leaving only the paths found so far, and ctx.Err() is returned
+func (graph *Graph) PruneContext(ctx context.Context) error {
 	indvs := graph.knowns
 	connected := mapset.NewSet()
-
-	for i := 0; i < len(indvs); i++ {
-		if src := graph.NodeNamed(indvs[i]); src != nil {
-			if shortest, ok := path.BellmanFordFrom(src, graph); ok {
-				for j := i + 1; j < len(indvs); j++ {
-					if dest := graph.NodeNamed(indvs[j]); dest != nil {
-						nodes, _ := shortest.To(dest.ID())
-						for _, node := range nodes {
-							connected.Add(node)
+	graph.support = make(map[[2]int64]int)
+	weighted := graph.cacheWeights()
+
+	// Search from each known in parallel, the union of nodes found
+	// does not depend on the order searches finish in
+	threads := graph.threads
+	if threads < 1 {
+		threads = 1
+	}
+	sources := make(chan int)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		stopped error
+	)
+	for t := 0; t < threads; t++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range sources {
+				if err := ctx.Err(); err != nil {
+					mu.Lock()
+					stopped = err // Sent as ctx was done, left unsearched
+					mu.Unlock()
+					continue
+				}
+				var found [][]gonumGraph.Node
+				if src := graph.NodeNamed(indvs[i]); src != nil {
+					if shortest, ok := weighted.shortestFrom(src); ok {
+						for j := i + 1; j < len(indvs); j++ {
+							if dest := graph.NodeNamed(indvs[j]); dest != nil {
+								if nodes, _ := shortest.To(dest.ID()); len(nodes) != 0 {
+									found = append(found, nodes)
+								}
+							}
 						}
 					}
 				}
+				mu.Lock()
+				for _, nodes := range found {
+					for _, node := range nodes {
+						connected.Add(node)
+					}
+					graph.addSupport(nodes)
+				}
+				mu.Unlock()
 			}
+		}()
+	}
+feed:
+	for i := 0; i < len(indvs); i++ {
+		select {
+		case sources <- i:
+		case <-ctx.Done():
+			mu.Lock()
+			stopped = ctx.Err()
+			mu.Unlock()
+			break feed
 		}
 	}
+	close(sources)
+	wg.Wait()
+	err := stopped
 
 	nodes := graph.Nodes()
 	for nodes.Next() {
 		n := nodes.Node()
-		if !connected.Contains(n) {
+		if !connected.Contains(n) && !graph.scaffold[n.ID()] {
 			graph.RemoveNode(n.ID())
 		}
 	}
 
 	// Remove bidirectional cycles between knowns through
 	// different unknowns
-	cycles := topo.UndirectedCyclesIn(graph)
+	var cycles [][]gonumGraph.Node
+	if err == nil {
+		cycles = topo.UndirectedCyclesIn(orderedGraph{graph})
+	}
 	var cyclesWUnknowns [][]gonumGraph.Node
 	for i, cycle := range cycles {
 		var hadUnknown bool
@@ -192,7 +430,7 @@ func (graph *Graph) Prune() {
 			if name, ok := graph.IDToName(node.ID()); ok {
 				if graph.IsKnown(name) {
 					deleting = !deleting
-				} else if deleting {
+				} else if deleting && !graph.scaffold[node.ID()] {
 					graph.RemoveNode(node.ID())
 				}
 			}
@@ -233,7 +471,7 @@ func (graph *Graph) Prune() {
 		}
 	}
 
-	return
+	return err
 }
 
 func (graph *Graph) IsKnown(name string) bool {
@@ -245,9 +483,22 @@ func (graph *Graph) IsKnown(name string) bool {
 	return false
 }
 
+// AddPath links each name in p to the next, tagging each link with
+// the relational distance of the whole path
+// Paths with a Distance beyond their number of links have their last
+// link marked as summarizing the rest
 func (graph *Graph) AddPath(p Path) {
 	names := p.Names()
 	weights := p.Weights()
+	dist := relational.Degree(len(weights))
+	if d, ok := p.(interface{ Distance() relational.Degree }); ok {
+		dist = d.Distance()
+	}
+	var carried string
+	if c, ok := p.(interface{ Carried() string }); ok {
+		carried = c.Carried()
+	}
+	input, traced := p.(interface{ Input() unit.Relatedness })
 
 	for i := range weights {
 		from := names[i]
@@ -257,11 +508,177 @@ func (graph *Graph) AddPath(p Path) {
 			graph.AddNodeNamed(from)
 			graph.AddNodeNamed(to)
 			edge := graph.NewWeightedEdgeNamed(from, to, weight)
+			// A link drawn again, as through a shared unknown, keeps the
+			// lightest weight of the relationships drawn through it
+			if prev := graph.WeightedEdge(edge.From().ID(), edge.To().ID()); prev != nil && prev.Weight() < edge.Weight() {
+				edge = prev
+			}
 			graph.SetWeightedEdge(edge)
+			graph.tagDistance(edge.From().ID(), edge.To().ID(), dist)
+			if carried != "" {
+				graph.carry(edge.From().ID(), edge.To().ID(), carried)
+			}
+			if i == len(weights)-1 && int(dist) > len(weights) {
+				graph.summarize(edge.From().ID(), edge.To().ID(), dist-relational.Degree(i))
+			}
+			if traced && graph.traces != nil {
+				graph.trace(edge.From().ID(), edge.To().ID(), Trace{
+					ID1:         names[0],
+					ID2:         names[len(names)-1],
+					Relatedness: input.Input(),
+					Distance:    dist,
+					Weight:      weight,
+				})
+			}
 		}
 	}
 }
 
+// summarize marks the link between x and y as standing in for span
+// generations, keeping the shortest span of any path using the link
+func (graph *Graph) summarize(x, y int64, span relational.Degree) {
+	if y < x {
+		x, y = y, x
+	}
+	if prev, ok := graph.summarized[[2]int64{x, y}]; !ok || span < prev {
+		graph.summarized[[2]int64{x, y}] = span
+	}
+}
+
+// span is the distance the link between x and y stands for when summarized, zero otherwise
+func (graph *Graph) span(x, y int64) relational.Degree {
+	if y < x {
+		x, y = y, x
+	}
+	return graph.summarized[[2]int64{x, y}]
+}
+
+// IsSummarized reports whether the link between n1 and n2 stands in
+// for unknowns beyond Options.MaxUnknownChain
+func (graph *Graph) IsSummarized(n1, n2 string) bool {
+	x, xOk := graph.NameToID(n1)
+	y, yOk := graph.NameToID(n2)
+	if !xOk || !yOk {
+		return false
+	}
+	if y < x {
+		x, y = y, x
+	}
+	_, ok := graph.summarized[[2]int64{x, y}]
+	return ok
+}
+
+// tagDistance records dist for the link between x and y,
+// keeping the closest distance of any path using the link
+func (graph *Graph) tagDistance(x, y int64, dist relational.Degree) {
+	if y < x {
+		x, y = y, x
+	}
+	if prev, ok := graph.edgeDists[[2]int64{x, y}]; !ok || dist < prev {
+		graph.edgeDists[[2]int64{x, y}] = dist
+	}
+}
+
+// carry records val, from the input, for the link between x and y
+func (graph *Graph) carry(x, y int64, val string) {
+	if y < x {
+		x, y = y, x
+	}
+	for _, prev := range graph.carried[[2]int64{x, y}] {
+		if prev == val {
+			return
+		}
+	}
+	graph.carried[[2]int64{x, y}] = append(graph.carried[[2]int64{x, y}], val)
+}
+
+// Carried are the input values of Options.CarryColumn for each
+// relationship drawn through the link between n1 and n2
+func (graph *Graph) Carried(n1, n2 string) []string {
+	x, xOk := graph.NameToID(n1)
+	y, yOk := graph.NameToID(n2)
+	if !xOk || !yOk {
+		return nil
+	}
+	if y < x {
+		x, y = y, x
+	}
+	return graph.carried[[2]int64{x, y}]
+}
+
+// addSupport counts one more shortest path through each link of nodes
+func (graph *Graph) addSupport(nodes []gonumGraph.Node) {
+	for i := 1; i < len(nodes); i++ {
+		x, y := nodes[i-1].ID(), nodes[i].ID()
+		if y < x {
+			x, y = y, x
+		}
+		graph.support[[2]int64{x, y}]++
+	}
+}
+
+// Support is the number of pairs of knowns whose shortest path, as found
+// while pruning, runs through the link between n1 and n2
+func (graph *Graph) Support(n1, n2 string) int {
+	x, xOk := graph.NameToID(n1)
+	y, yOk := graph.NameToID(n2)
+	if !xOk || !yOk {
+		return 0
+	}
+	if y < x {
+		x, y = y, x
+	}
+	return graph.support[[2]int64{x, y}]
+}
+
+// EdgeDistance is the relational distance of the closest relationship
+// drawn through the link between n1 and n2
+// Returns false if the link was not added by a path
+func (graph *Graph) EdgeDistance(n1, n2 string) (relational.Degree, bool) {
+	x, xOk := graph.NameToID(n1)
+	y, yOk := graph.NameToID(n2)
+	if !xOk || !yOk {
+		return relational.Unrelated, false
+	}
+	if y < x {
+		x, y = y, x
+	}
+	dist, ok := graph.edgeDists[[2]int64{x, y}]
+	return dist, ok
+}
+
+// linkDistance is the relational distance the link between x and y
+// stands for, one generation unless it summarizes capped unknowns
+// Returns false for links added by BridgeBy, which are assumed
+// rather than drawn from relatedness so have no distance
+func (graph *Graph) linkDistance(x, y int64) (uint, bool) {
+	if y < x {
+		x, y = y, x
+	}
+	if graph.bridged[[2]int64{x, y}] {
+		return 0, false
+	}
+	if span, ok := graph.summarized[[2]int64{x, y}]; ok {
+		return uint(span), true
+	}
+	return 1, true
+}
+
+// pathDistance is the relational distance along nodes, the sum of
+// the distance each link stands for
+// Returns false if any link has no distance
+func (graph *Graph) pathDistance(nodes []gonumGraph.Node) (uint, bool) {
+	var dist uint
+	for i := 1; i < len(nodes); i++ {
+		d, ok := graph.linkDistance(nodes[i-1].ID(), nodes[i].ID())
+		if !ok {
+			return 0, false
+		}
+		dist += d
+	}
+	return dist, true
+}
+
 // IDToName converts the id to its corresponding node name
 // Returns false if the node does not exist
 func (graph *Graph) IDToName(id int64) (string, bool) {
@@ -280,15 +697,6 @@ func (graph *Graph) NameToID(name string) (int64, bool) {
 	return info.ID, ok
 }
 
-func (graph *Graph) RmDisconnected() {
-	for name := range graph.nameToInfo {
-		nodes := graph.FromNamed(name)
-		if nodes.Len() == 0 {
-			graph.RemoveNodeNamed(name)
-		}
-	}
-}
-
 func (graph *Graph) Weight(xid, yid int64) (w float64, ok bool) {
 	return graph.wug.Weight(xid, yid)
 }
@@ -329,9 +737,54 @@ func (graph *Graph) Nodes() gonumGraph.Nodes {
 	return graph.wug.Nodes()
 }
 
+// UseStableIDs derives the ID of each node added after from a hash of its name,
+// so the same names have the same IDs across runs regardless of insertion order
+func (graph *Graph) UseStableIDs() {
+	graph.stableIDs = true
+}
+
+// UseSeed names unknowns added after from a random source seeded with
+// seed, so the same input gives the same names across runs
+func (graph *Graph) UseSeed(seed int64) {
+	graph.rng = rand.New(rand.NewSource(seed))
+	graph.rngNames = make(map[string]bool)
+}
+
+// unknownNamer names unknowns with prefix followed by a unique suffix
+// Seeded names skip any already given to a known or other node
+func (graph *Graph) unknownNamer(prefix string) func() string {
+	if graph.rng == nil {
+		return xidNamer(prefix)
+	}
+	const digits = "0123456789abcdefghijklmnopqrstuv"
+	return func() string {
+		for {
+			suffix := make([]byte, lenUnknownNames)
+			for i := range suffix {
+				suffix[i] = digits[graph.rng.Intn(len(digits))]
+			}
+			name := prefix + string(suffix)
+			if _, taken := graph.nameToInfo[name]; !taken && !graph.rngNames[name] && !graph.IsKnown(name) {
+				graph.rngNames[name] = true
+				return name
+			}
+		}
+	}
+}
+
+// UseThreads searches from up to n knowns at once while pruning
+func (graph *Graph) UseThreads(n int) {
+	graph.threads = n
+}
+
 func (graph *Graph) AddNodeNamed(name string) {
 	if _, ok := graph.nameToInfo[name]; !ok {
-		n := graph.NewNode()
+		var n gonumGraph.Node
+		if graph.stableIDs {
+			n = graph.newNodeFromName(name)
+		} else {
+			n = graph.NewNode()
+		}
 		graph.AddNode(n)
 		info := graph.nameToInfo[name]
 		info.ID = n.ID()
@@ -339,6 +792,22 @@ func (graph *Graph) AddNodeNamed(name string) {
 	}
 }
 
+// newNodeFromName creates a node with an ID hashed from name,
+// probing forward past IDs already in use
+func (graph *Graph) newNodeFromName(name string) gonumGraph.Node {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	id := int64(h.Sum64() &^ (1 << 63))
+	for graph.Node(id) != nil {
+		if id == math.MaxInt64 {
+			id = 0
+		} else {
+			id++
+		}
+	}
+	return simple.Node(id)
+}
+
 func (graph *Graph) NewNode() gonumGraph.Node {
 	return graph.wug.NewNode()
 }
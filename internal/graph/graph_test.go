@@ -1,9 +1,24 @@
 package graph_test
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/demographics"
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/pedigree"
+	"github.com/rhagenson/relped/internal/unit"
+	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
+	"gonum.org/v1/gonum/graph/path"
 )
 
 func TestGraph(t *testing.T) {
@@ -43,4 +58,1141 @@ func TestGraph(t *testing.T) {
 			t.Errorf("Bowtie was not removed. Offspring with the same parents remained connected:\n%s", g.String())
 		}
 	})
+	t.Run("Cancelled pruning returns context error", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := g.PruneContext(ctx); err != context.Canceled {
+			t.Errorf("Got %v, Expected %v", err, context.Canceled)
+		}
+	})
+	t.Run("Cancelled pruning of a large graph returns promptly", func(t *testing.T) {
+		indvs := make([]string, 300)
+		for i := range indvs {
+			indvs[i] = "I" + strconv.Itoa(i)
+		}
+		g := graph.NewGraph(indvs)
+		g.UseThreads(2)
+		for i := range indvs {
+			for j := i + 1; j < len(indvs) && j <= i+10; j++ {
+				if p, err := graph.NewRelationalWeightPath(indvs[i], indvs[j], relational.Degree(1+(i+j)%4), 1, graph.EqualScheme, ""); err == nil {
+					g.AddPath(p)
+				}
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := g.PruneContext(ctx)
+		if err != context.DeadlineExceeded {
+			t.Errorf("Got %v, Expected %v", err, context.DeadlineExceeded)
+		}
+		if took := time.Since(start); time.Second < took {
+			t.Errorf("Pruning took %s after being cancelled, Expected it to stop promptly", took)
+		}
+	})
+	t.Run("Missing relatedness is imputed along shortest path", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		imputed := g.Impute([][2]string{{"I1", "I2"}, {"I3", "I2"}})
+		if len(imputed) != 1 {
+			t.Fatalf("Got %d imputed pairs, Expected 1: %v", len(imputed), imputed)
+		}
+		got := imputed[0]
+		if got.ID1 != "I1" || got.ID2 != "I3" || got.Distance != 3 || got.Relatedness != 0.125 {
+			t.Errorf("Got %+v, Expected I1 and I3 at distance 3 with relatedness 0.125", got)
+		}
+	})
+	t.Run("Imputed relatedness halves for each generation a link stands for", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		p, err := graph.NewCappedRelationalWeightPath("I1", "I2", relational.Fourth, 1, graph.EqualScheme, "", 1)
+		if err != nil {
+			t.Fatalf("Could not create path: %s", err)
+		}
+		g.AddPath(p)
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		imputed := g.Impute([][2]string{{"I1", "I2"}, {"I2", "I3"}})
+		if len(imputed) != 1 {
+			t.Fatalf("Got %d imputed pairs, Expected 1: %v", len(imputed), imputed)
+		}
+		if got := imputed[0]; got.Distance != 5 || got.Relatedness != 0.03125 {
+			t.Errorf("Got %+v, Expected I1 and I3 at distance 5 with relatedness 0.03125", got)
+		}
+	})
+	t.Run("Stable IDs do not depend on insertion order", func(t *testing.T) {
+		g1 := graph.NewGraph([]string{"I1", "I2"})
+		g1.UseStableIDs()
+		g1.AddNodeNamed("I1")
+		g1.AddNodeNamed("I2")
+		g2 := graph.NewGraph([]string{"I1", "I2"})
+		g2.UseStableIDs()
+		g2.AddNodeNamed("I2")
+		g2.AddNodeNamed("I1")
+		for _, name := range []string{"I1", "I2"} {
+			id1, _ := g1.NameToID(name)
+			id2, _ := g2.NameToID(name)
+			if id1 != id2 {
+				t.Errorf("Got ID %d and %d for %s, Expected equal IDs", id1, id2, name)
+			}
+		}
+	})
+	t.Run("Explain follows the shortest path", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "U2", "I2"}, 4))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U3", "I2"}, 1))
+		exp, err := g.Explain("I1", "I2")
+		if err != nil {
+			t.Fatalf("Could not explain: %s", err)
+		}
+		if exp.Distance != 2 || exp.Names[1] != "U3" {
+			t.Errorf("Got %+v, Expected path through U3 at distance 2", exp)
+		}
+		if _, err := g.Explain("I1", "I3"); err == nil {
+			t.Errorf("Expected error explaining individual not in graph")
+		}
+	})
+	t.Run("Explain counts the distance summarized links stand for", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		p, err := graph.NewCappedRelationalWeightPath("I1", "I2", relational.Fifth, 1, graph.EqualScheme, "", 1)
+		if err != nil {
+			t.Fatalf("Could not create path: %s", err)
+		}
+		g.AddPath(p)
+		exp, err := g.Explain("I1", "I2")
+		if err != nil {
+			t.Fatalf("Could not explain: %s", err)
+		}
+		if len(exp.Names) != 3 || exp.Distance != 5 {
+			t.Errorf("Got %+v, Expected two links at distance 5", exp)
+		}
+	})
+	t.Run("Explain gives no distance through a bridge", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		for _, name := range []string{"I1", "I2"} {
+			g.AddNodeNamed(name)
+			g.AddFamily(name, "A")
+		}
+		g.BridgeBy("family", "U")
+		exp, err := g.Explain("I1", "I2")
+		if err != nil {
+			t.Fatalf("Could not explain: %s", err)
+		}
+		if exp.Distance != 0 {
+			t.Errorf("Got distance %d, Expected none through a bridge", exp.Distance)
+		}
+		if desc := exp.Describe(g.IsKnown); !strings.Contains(desc, "assumed bridge") {
+			t.Errorf("Got %q, Expected the bridge to be described", desc)
+		}
+	})
+	t.Run("Inbreeding is estimated from loops", func(t *testing.T) {
+		// I1 has neighbors U1 and U2 which share the ancestor U3
+		g := graph.NewGraph([]string{"I1", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"U1", "I1", "U2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"U1", "U3", "U2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		inbred := g.Inbreeding()
+		if len(inbred) != 2 {
+			t.Fatalf("Got %d estimates, Expected 2: %v", len(inbred), inbred)
+		}
+		if inbred[0].ID != "I1" || inbred[0].F != 0.125 {
+			t.Errorf("Got %+v, Expected I1 with F=0.125", inbred[0])
+		}
+		if inbred[1].ID != "I2" || inbred[1].F != 0 {
+			t.Errorf("Got %+v, Expected I2 with F=0", inbred[1])
+		}
+	})
+	t.Run("Validate flags more than two unknown parents", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"U1", "I1", "U2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U3", "I3"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"U1", "I2", "U2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U4", "U5", "I2"}, 1)) // Not a parent
+		invalid := g.Validate()
+		if len(invalid) != 1 || invalid[0].ID != "I1" || len(invalid[0].Unknowns) != 3 {
+			t.Errorf("Got %+v, Expected only I1 with three unknowns", invalid)
+		}
+	})
+	t.Run("Validate passes cousins", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\n" +
+			"I1,I2,0.0625\n" +
+			"I1,I3,0.0625\n" +
+			"I1,I4,0.0625\n" +
+			"I2,I3,0.0625\n" +
+			"I2,I4,0.0625\n" +
+			"I3,I4,0.0625\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{MergeReciprocal: true})
+		g.Prune()
+		if invalid := g.Validate(); len(invalid) != 0 {
+			t.Errorf("Got %+v, Expected each with three cousins to be valid", invalid)
+		}
+	})
+	t.Run("Unrelated ML-Relate pairs have no edges", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("Ind1,Ind2,R,LnL(R),U,HS,FS,PO,Relationships,Relatedness\n" +
+			"I1,I2,U,-25.37,-,-31.6,-27.78,-29.59,U,0.000\n" +
+			"I1,I3,U,-37.98,-,-44.4,-39.18,-38.69,U,0.020\n")
+		f.Seek(0, 0)
+		in := relatedness.NewMLRelateCsv(f, relatedness.Options{})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{})
+		if n := g.Edges().Len(); n != 0 {
+			t.Errorf("Got %d edges, Expected none between unrelated individuals", n)
+		}
+	})
+	t.Run("Distances are symmetric with zero for disconnected", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I3", "I2", "I1", "I4"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		g.AddNodeNamed("I4")
+		names, dists := g.Distances()
+		if names[0] != "I1" || names[3] != "I4" {
+			t.Errorf("Got %v, Expected sorted names", names)
+		}
+		exp := [][]uint{
+			{0, 2, 3, 0},
+			{2, 0, 1, 0},
+			{3, 1, 0, 0},
+			{0, 0, 0, 0},
+		}
+		for i := range exp {
+			for j := range exp[i] {
+				if dists[i][j] != exp[i][j] {
+					t.Errorf("Got %v, Expected %v", dists, exp)
+					return
+				}
+			}
+		}
+	})
+	t.Run("Distances count the distance summarized links stand for", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		p, err := graph.NewCappedRelationalWeightPath("I1", "I2", relational.Sixth, 1, graph.EqualScheme, "", 2)
+		if err != nil {
+			t.Fatalf("Could not create path: %s", err)
+		}
+		g.AddPath(p)
+		for _, name := range []string{"I2", "I3"} {
+			g.AddNodeNamed(name)
+			g.AddFamily(name, "A")
+		}
+		g.BridgeBy("family", "U")
+		_, dists := g.Distances()
+		if dists[0][1] != 6 {
+			t.Errorf("Got distance %d between I1 and I2, Expected 6", dists[0][1])
+		}
+		if dists[1][2] != 0 || dists[0][2] != 0 {
+			t.Errorf("Got %v, Expected no distance through the bridge to I3", dists)
+		}
+	})
+	t.Run("Pruning does not depend on threads", func(t *testing.T) {
+		indvs := []string{"I1", "I2", "I3", "I4", "I5"}
+		var first string
+		for _, threads := range []int{1, 2, 8} {
+			g := graph.NewGraph(indvs)
+			g.UseThreads(threads)
+			g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+			g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U2", "U3", "I3"}, 1))
+			g.AddPath(graph.NewEqualWeightPath([]string{"I2", "U4", "I3"}, 1))
+			g.AddPath(graph.NewEqualWeightPath([]string{"I3", "I4"}, 1))
+			g.AddPath(graph.NewEqualWeightPath([]string{"I4", "U5", "U6", "I5"}, 1))
+			g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U7", "U8", "U9", "I5"}, 1))
+			g.Prune()
+			ped, _ := pedigree.NewPedigreeFromGraph(g, indvs, pedigree.Options{})
+			if threads == 1 {
+				first = ped.String()
+			} else if got := ped.String(); got != first {
+				t.Errorf("Got with %d threads:\n%s\nExpected as with 1 thread:\n%s", threads, got, first)
+			}
+		}
+	})
+	t.Run("Cancelled pruning stops every thread", func(t *testing.T) {
+		indvs := make([]string, 300)
+		for i := range indvs {
+			indvs[i] = "I" + strconv.Itoa(i)
+		}
+		for _, threads := range []int{1, 2, 8} {
+			g := graph.NewGraph(indvs)
+			g.UseThreads(threads)
+			for i := range indvs {
+				for j := i + 1; j < len(indvs) && j <= i+10; j++ {
+					if p, err := graph.NewRelationalWeightPath(indvs[i], indvs[j], relational.Degree(1+(i+j)%4), 1, graph.EqualScheme, ""); err == nil {
+						g.AddPath(p)
+					}
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			start := time.Now()
+			err := g.PruneContext(ctx)
+			took := time.Since(start)
+			cancel()
+			if err != context.DeadlineExceeded {
+				t.Errorf("Got %v with %d threads, Expected %v", err, threads, context.DeadlineExceeded)
+			}
+			if time.Second < took {
+				t.Errorf("Pruning with %d threads took %s after being cancelled, Expected it to stop promptly", threads, took)
+			}
+		}
+	})
+	t.Run("Isolated unknowns are removed", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"U1", "U2", "U3"}, 1))       // Dead-end branch
+		g.AddPath(graph.NewEqualWeightPath([]string{"U4", "U5", "U6", "U4"}, 1)) // Only unknowns
+		g.RmIsolatedUnknowns()
+		for _, name := range []string{"U2", "U3", "U4", "U5", "U6"} {
+			if _, ok := g.NameToID(name); ok {
+				t.Errorf("Expected %s to be removed", name)
+			}
+		}
+		if !g.HasEdgeBetweenNamed("I1", "U1") || !g.HasEdgeBetweenNamed("U1", "I2") {
+			t.Errorf("Expected path between knowns to remain")
+		}
+	})
+	t.Run("Small components are removed", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3", "I4", "I5"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I4", "U2", "I5"}, 1)) // Only two knowns
+		comps, knowns := g.RmSmallComponents(3)
+		if comps != 1 || knowns != 2 {
+			t.Errorf("Got %d components and %d knowns removed, Expected 1 and 2", comps, knowns)
+		}
+		for _, name := range []string{"I4", "U2", "I5"} {
+			if _, ok := g.NameToID(name); ok {
+				t.Errorf("Expected %s to be removed", name)
+			}
+		}
+		if !g.HasEdgeBetweenNamed("I2", "I3") {
+			t.Errorf("Expected larger component to remain")
+		}
+	})
+	t.Run("Disconnected individuals are removed", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddNodeNamed("I3")
+		g.AddNodeNamed("U2")
+		if n := g.RmDisconnected(); n != 2 {
+			t.Errorf("Got %d individuals removed, Expected 2", n)
+		}
+		nodes := g.Nodes()
+		for nodes.Next() {
+			if g.From(nodes.Node().ID()).Len() == 0 {
+				name, _ := g.IDToName(nodes.Node().ID())
+				t.Errorf("Expected %s to be removed", name)
+			}
+		}
+		if g.Nodes().Len() != 3 {
+			t.Errorf("Got %d individuals left, Expected 3", g.Nodes().Len())
+		}
+	})
+	t.Run("Unknowns list the knowns they link", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "U2", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"U1", "I3"}, 1))
+		exp := []graph.Unknown{
+			{ID: "U1", Knowns: []string{"I1", "I2", "I3"}},
+			{ID: "U2", Knowns: []string{"I1", "I2", "I3"}},
+		}
+		got := g.Unknowns()
+		if len(got) != len(exp) {
+			t.Fatalf("Got %+v, Expected %+v", got, exp)
+		}
+		for i := range got {
+			if got[i].ID != exp[i].ID || strings.Join(got[i].Knowns, ",") != strings.Join(exp[i].Knowns, ",") {
+				t.Errorf("Got %+v, Expected %+v", got[i], exp[i])
+			}
+		}
+	})
+	t.Run("Relationships are limited by distance", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		tt := []struct {
+			maxDist uint
+			exp     []graph.Relationship
+		}{
+			{1, []graph.Relationship{{ID1: "I2", ID2: "I3", Distance: 1, Weight: 1}}},
+			{2, []graph.Relationship{
+				{ID1: "I1", ID2: "I2", Distance: 2, Weight: 2},
+				{ID1: "I2", ID2: "I3", Distance: 1, Weight: 1},
+			}},
+			{0, []graph.Relationship{
+				{ID1: "I1", ID2: "I2", Distance: 2, Weight: 2},
+				{ID1: "I1", ID2: "I3", Distance: 3, Weight: 3},
+				{ID1: "I2", ID2: "I3", Distance: 1, Weight: 1},
+			}},
+		}
+		for _, tc := range tt {
+			got := g.Relationships(tc.maxDist)
+			if len(got) != len(tc.exp) {
+				t.Errorf("Got %+v within %d, Expected %+v", got, tc.maxDist, tc.exp)
+				continue
+			}
+			for i := range got {
+				if got[i] != tc.exp[i] {
+					t.Errorf("Got %+v within %d, Expected %+v", got, tc.maxDist, tc.exp)
+					break
+				}
+			}
+		}
+	})
+	t.Run("Relationships are limited by the distance summarized links stand for", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		p, err := graph.NewCappedRelationalWeightPath("I1", "I2", relational.Fifth, 2, graph.EqualScheme, "", 1)
+		if err != nil {
+			t.Fatalf("Could not create path: %s", err)
+		}
+		g.AddPath(p)
+		if got := g.Relationships(4); len(got) != 0 {
+			t.Errorf("Got %+v within 4, Expected none", got)
+		}
+		if got := g.Relationships(5); len(got) != 1 || got[0].Distance != 5 {
+			t.Errorf("Got %+v within 5, Expected I1 and I2 at distance 5", got)
+		}
+	})
+	t.Run("Full siblings share two unknown parents", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("Ind1,Ind2,R,LnL(R),U,HS,FS,PO,Relationships,Relatedness\n" +
+			"I1,I2,FS,-25.37,-31.6,-27.78,-,-29.59,FS,0.25\n")
+		f.Seek(0, 0)
+		in := relatedness.NewMLRelateCsv(f, relatedness.Options{})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{CategoryTopology: true})
+		g.Prune()
+		if n := g.FromNamed("I1").Len(); n != 2 {
+			t.Errorf("Got %d parents of I1, Expected 2", n)
+		}
+		parents := g.FromNamed("I1")
+		for parents.Next() {
+			if !g.HasEdgeBetween(parents.Node().ID(), g.NodeNamed("I2").ID()) {
+				t.Errorf("Expected parents of I1 to be parents of I2")
+			}
+		}
+	})
+	t.Run("Cached graph is restored", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 2))
+		g.AddSex("I1", demographics.Female)
+		buf := new(bytes.Buffer)
+		if err := g.WriteCache(buf); err != nil {
+			t.Fatalf("Could not write cache: %s", err)
+		}
+		restored, err := graph.ReadCache(buf)
+		if err != nil {
+			t.Fatalf("Could not read cache: %s", err)
+		}
+		if w, ok := restored.WeightNamed("I1", "U1"); !ok || w != 2 {
+			t.Errorf("Got weight %v (%t), Expected 2", w, ok)
+		}
+		if !restored.IsKnown("I2") || restored.IsKnown("U1") {
+			t.Errorf("Expected knowns to be restored")
+		}
+		if restored.Info("I1").Sex != demographics.Female {
+			t.Errorf("Expected information to be restored")
+		}
+	})
+	t.Run("Distance weights grow by one per generation", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\n" +
+			"I1,I2,0.5\n" +
+			"I3,I4,0.125\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{DistanceWeights: true})
+		if w, ok := g.WeightNamed("I1", "I2"); !ok || w != 2 {
+			t.Errorf("Got weight %v (%t) for first degree, Expected 2", w, ok)
+		}
+		links := g.FromNamed("I3")
+		for links.Next() {
+			if w, _ := g.Weight(g.NodeNamed("I3").ID(), links.Node().ID()); 1e-9 < math.Abs(w-4.0/3) {
+				t.Errorf("Got weight %v for each third degree link, Expected 4/3", w)
+			}
+		}
+	})
+	t.Run("Pairs drawn at another distance are detours", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\n" +
+			"I1,I2,0.5\n" +
+			"I2,I3,0.5\n" +
+			"I1,I3,0.0625\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{})
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2", "I3"}, 1))
+		exp := graph.Detour{ID1: "I1", ID2: "I3", Direct: relational.Fourth, Drawn: 2}
+		if detours := g.Detours(in, relational.Unrelated, relational.Unrelated); len(detours) != 1 || detours[0] != exp {
+			t.Errorf("Got %+v, Expected %+v", detours, exp)
+		}
+		if detours := g.Detours(in, relational.Unrelated, relational.Third); len(detours) != 0 {
+			t.Errorf("Got %+v, Expected none within third degree", detours)
+		}
+	})
+	t.Run("Relatedness power prefers paths through close relationships", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\n" +
+			"I1,I2,0.5\n" +
+			"I2,I3,0.5\n" +
+			"I1,I3,0.3\n")
+		for _, tc := range []struct {
+			power     float64
+			throughI2 bool
+		}{
+			{1, false}, // Weight 1/0.3 of I1-I3 is less than 2+2 through I2
+			{2, true},  // Weight 1/0.09 of I1-I3 is more than 4+4 through I2
+		} {
+			f.Seek(0, 0)
+			in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+			g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{MergeReciprocal: true, RelatednessPower: tc.power})
+			nodes, _ := path.DijkstraFrom(g.NodeNamed("I1"), g).To(g.NodeNamed("I3").ID())
+			throughI2 := false
+			for _, node := range nodes {
+				if name, _ := g.IDToName(node.ID()); name == "I2" {
+					throughI2 = true
+				}
+			}
+			if throughI2 != tc.throughI2 {
+				t.Errorf("Got shortest path through I2 %t at power %v, Expected %t", throughI2, tc.power, tc.throughI2)
+			}
+		}
+	})
+	t.Run("Betweenness pruning keeps a backbone between knowns", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3", "I4"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U2", "I2"}, 1)) // Redundant with U1
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I3", "I4"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "U3", "I4"}, 1)) // Closes a loop
+		g.PruneBetweenness()
+		if n := g.Edges().Len(); n != 4 {
+			t.Errorf("Got %d links, Expected a tree of 4", n)
+		}
+		if _, dists := g.Distances(); dists[0][3] == 0 {
+			t.Errorf("Expected every known to stay connected")
+		}
+		if g.NodeNamed("U1") != nil && g.NodeNamed("U2") != nil {
+			t.Errorf("Expected only one of the redundant unknowns to remain")
+		}
+		if !g.HasEdgeBetweenNamed("I2", "I3") || !g.HasEdgeBetweenNamed("I3", "I4") {
+			t.Errorf("Expected the most central links to remain")
+		}
+	})
+	t.Run("Pruning keeps direct first degree links bypassed by shorter paths", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		for _, tc := range []struct {
+			from, to string
+			weight   unit.Weight
+		}{
+			{"I1", "I2", 10},
+			{"I1", "I3", 1},
+			{"I3", "I2", 1},
+		} {
+			p, _ := graph.NewRelationalWeightPath(tc.from, tc.to, relational.First, tc.weight, graph.EqualScheme, "U")
+			g.AddPath(p)
+		}
+		g.Prune()
+		if !g.HasEdgeBetweenNamed("I1", "I2") {
+			t.Errorf("Expected the first degree link between I1 and I2 to remain")
+		}
+	})
+	t.Run("Betweenness pruning keeps direct first degree links", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		for _, pair := range [][2]string{{"I1", "I2"}, {"I1", "I3"}, {"I2", "I3"}} {
+			p, _ := graph.NewRelationalWeightPath(pair[0], pair[1], relational.First, 2, graph.EqualScheme, "U")
+			g.AddPath(p)
+		}
+		g.PruneBetweenness()
+		for _, pair := range [][2]string{{"I1", "I2"}, {"I1", "I3"}, {"I2", "I3"}} {
+			if !g.HasEdgeBetweenNamed(pair[0], pair[1]) {
+				t.Errorf("Expected the first degree link between %s and %s to remain", pair[0], pair[1])
+			}
+		}
+	})
+	t.Run("Links count the shortest paths through them", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3", "I4"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I3", "I4"}, 1))
+		g.Prune()
+		for _, tc := range []struct {
+			from, to string
+			support  int
+		}{
+			{"I1", "U1", 3}, // I1 to each other known
+			{"I2", "I3", 4}, // I1 and I2 to I3 and I4
+			{"I4", "I3", 3}, // Each other known to I4
+		} {
+			if n := g.Support(tc.from, tc.to); n != tc.support {
+				t.Errorf("Got support %d between %s and %s, Expected %d", n, tc.from, tc.to, tc.support)
+			}
+		}
+		buf := new(bytes.Buffer)
+		if err := g.WriteCache(buf); err != nil {
+			t.Fatalf("Could not write cache: %s", err)
+		}
+		if restored, err := graph.ReadCache(buf); err != nil || restored.Support("I2", "I3") != 4 {
+			t.Errorf("Expected support to be restored from the cache")
+		}
+	})
+	t.Run("Composite IDs give families", func(t *testing.T) {
+		g := graph.NewGraphFromEdges([]graph.Edge{
+			{From: "A_I1", To: "A_I2", Relatedness: 0.5},
+			{From: "A_I2", To: "I3", Relatedness: 0.5},
+		}, graph.Options{MergeReciprocal: true, IDDelimiter: "_"})
+		for name, exp := range map[string]string{"A_I1": "A", "A_I2": "A", "I3": ""} {
+			if got := g.Info(name).Family; got != exp {
+				t.Errorf("Got family %q of %s, Expected %q", got, name, exp)
+			}
+		}
+		if !g.HasEdgeBetweenNamed("A_I1", "A_I2") {
+			t.Errorf("Expected composite IDs to be kept whole as names")
+		}
+	})
+	t.Run("Split individuals are not linked", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\n" +
+			"A_I1,A_I2,0.5\n" +
+			"A_I2,B_I3,0.5\n" +
+			"B_I3,B_I4,0.25\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{
+			MergeReciprocal: true,
+			IDDelimiter:     "_",
+			SplitBy:         "family",
+		})
+		if g.HasEdgeBetweenNamed("A_I2", "B_I3") {
+			t.Errorf("Expected no link between families")
+		}
+		parts := g.Partitions("family")
+		if len(parts) != 2 || len(parts["A"]) != 2 || len(parts["B"]) != 2 {
+			t.Fatalf("Got partitions %v, Expected two of two individuals", parts)
+		}
+		sub := g.Subgraph(parts["B"])
+		if sub.NodeNamed("A_I1") != nil {
+			t.Errorf("Expected no individuals of other families in the subgraph")
+		}
+		if n := sub.Nodes().Len(); n != 3 {
+			t.Errorf("Got %d nodes, Expected two knowns and their unknown", n)
+		}
+		if n := sub.Edges().Len(); n != 2 {
+			t.Errorf("Got %d links, Expected the two linking B_I3 and B_I4", n)
+		}
+	})
+	t.Run("Bridging joins separate groups sharing information", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\n" +
+			"A_I1,A_I2,0.5\n" +
+			"A_I3,A_I4,0.5\n" +
+			"B_I5,B_I6,0.5\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{
+			MergeReciprocal: true,
+			IDDelimiter:     "_",
+		})
+		if n := g.BridgeBy("family", "U"); n != 1 {
+			t.Fatalf("Got %d bridges, Expected one for family A", n)
+		}
+		nodes, _ := path.DijkstraFrom(g.NodeNamed("A_I1"), g).To(g.NodeNamed("A_I3").ID())
+		if len(nodes) == 0 {
+			t.Errorf("Expected the family A groups to be joined")
+		}
+		if nodes, _ := path.DijkstraFrom(g.NodeNamed("A_I1"), g).To(g.NodeNamed("B_I5").ID()); len(nodes) != 0 {
+			t.Errorf("Expected other families to be left apart")
+		}
+		unknowns := g.Unknowns()
+		if len(unknowns) != 1 {
+			t.Fatalf("Got %d unknowns, Expected the bridging unknown alone", len(unknowns))
+		}
+		if !g.IsBridged("A_I1", unknowns[0].ID) || !g.IsBridged("A_I3", unknowns[0].ID) {
+			t.Errorf("Expected the links to the bridging unknown to be bridges")
+		}
+		if g.IsBridged("A_I1", "A_I2") {
+			t.Errorf("Expected links from relatedness not to be bridges")
+		}
+	})
+	t.Run("Tracing keeps the relationships drawn through each link", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\nI1,I2,0.25\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{
+			MergeReciprocal:  true,
+			RelatednessPower: 2,
+			Trace:            true,
+		})
+		unknowns := g.Unknowns()
+		if len(unknowns) != 1 {
+			t.Fatalf("Got %d unknowns, Expected one between second degree relatives", len(unknowns))
+		}
+		traces := g.Traces("I1", unknowns[0].ID)
+		if len(traces) != 1 {
+			t.Fatalf("Got %d traces, Expected one", len(traces))
+		}
+		if tr := traces[0]; tr.ID1 != "I1" || tr.ID2 != "I2" || tr.Relatedness != 0.25 || tr.Distance != relational.Second {
+			t.Errorf("Got %+v, Expected I1 and I2 at 0.25 relatedness and second degree", tr)
+		}
+		var buf bytes.Buffer
+		if err := g.WriteCache(&buf); err != nil {
+			t.Fatalf("Could not write cache: %s", err)
+		}
+		restored, err := graph.ReadCache(&buf)
+		if err != nil {
+			t.Fatalf("Could not read cache: %s", err)
+		}
+		if got := restored.Traces("I1", unknowns[0].ID); len(got) != 1 || got[0] != traces[0] {
+			t.Errorf("Got %+v, Expected traces restored from the cache", got)
+		}
+	})
+	t.Run("Sharing unknowns draws half siblings from one parent", func(t *testing.T) {
+		for _, tc := range []struct {
+			share    bool
+			unknowns int
+		}{
+			{false, 3},
+			{true, 1},
+		} {
+			f, err := ioutil.TempFile("", "relped-*.csv")
+			if err != nil {
+				t.Fatalf("Could not create temporary file: %s", err)
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			f.WriteString("ID1,ID2,Rel\nI1,I2,0.25\nI1,I3,0.25\nI2,I3,0.25\n")
+			f.Seek(0, 0)
+			in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+			g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{
+				MergeReciprocal: true,
+				ShareUnknowns:   tc.share,
+			})
+			if n := len(g.Unknowns()); n != tc.unknowns {
+				t.Errorf("Got %d unknowns sharing %t, Expected %d", n, tc.share, tc.unknowns)
+			}
+		}
+	})
+	t.Run("Sharing unknowns keeps unrelated siblings apart", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\nI1,I2,0.25\nI1,I3,0.25\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{
+			MergeReciprocal: true,
+			ShareUnknowns:   true,
+		})
+		if n := len(g.Unknowns()); n != 2 {
+			t.Errorf("Got %d unknowns, Expected one for each pair as I2 and I3 are unrelated", n)
+		}
+	})
+	t.Run("Sharing unknowns keeps every relationship through a shared link", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\nI1,I2,0.25\nI1,I3,0.3\nI2,I3,0.25\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{
+			MergeReciprocal: true,
+			ShareUnknowns:   true,
+			Trace:           true,
+		})
+		unknowns := g.Unknowns()
+		if len(unknowns) != 1 {
+			t.Fatalf("Got %d unknowns, Expected three siblings sharing one", len(unknowns))
+		}
+		u := unknowns[0].ID
+		w1, _ := g.WeightNamed(u, "I1")
+		w2, _ := g.WeightNamed(u, "I2")
+		w3, _ := g.WeightNamed(u, "I3")
+		if w1 != w3 || w2 <= w3 {
+			t.Errorf("Got weights %v, %v, and %v, Expected I1 and I3 to keep their closer relationship", w1, w2, w3)
+		}
+		if d, ok := g.EdgeDistance(u, "I3"); !ok || d != relational.Second {
+			t.Errorf("Got %v (%t), Expected second degree", d, ok)
+		}
+		if n := len(g.Traces(u, "I3")); n != 2 {
+			t.Errorf("Got %d relationships traced through the shared link, Expected 2", n)
+		}
+	})
+	t.Run("Keeping the lightest links drops the weakest relationships", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		first, _ := graph.NewRelationalWeightPath("I1", "I2", relational.First, 2, graph.EqualScheme, "U")
+		g.AddPath(first)
+		second, _ := graph.NewRelationalWeightPath("I2", "I3", relational.Second, 8, graph.EqualScheme, "U")
+		g.AddPath(second)
+		if removed := g.KeepLightest(2); removed != 2 {
+			t.Errorf("Got %d links removed, Expected 2", removed)
+		}
+		if !g.HasEdgeBetweenNamed("I1", "I2") {
+			t.Errorf("Expected the strongest relationship to be kept")
+		}
+		if n := g.Nodes().Len(); n != 3 {
+			t.Errorf("Got %d nodes, Expected the unknown left on a dead end removed", n)
+		}
+		if removed := g.KeepLightest(2); removed != 0 {
+			t.Errorf("Got %d links removed, Expected none within the limit", removed)
+		}
+	})
+	t.Run("Graphs are built from edges in memory", func(t *testing.T) {
+		g := graph.NewGraphFromEdges([]graph.Edge{
+			{From: "I1", To: "I2", Relatedness: 0.5},
+			{From: "I2", To: "I3", Relatedness: 0.25},
+			{From: "I3", To: "I1", Relatedness: 0},
+		}, graph.Options{MergeReciprocal: true})
+		if !g.HasEdgeBetweenNamed("I1", "I2") {
+			t.Errorf("Expected a direct link between first degree relatives")
+		}
+		if n := g.Nodes().Len(); n != 4 {
+			t.Errorf("Got %d nodes, Expected three knowns and one unknown", n)
+		}
+		if n := g.Edges().Len(); n != 3 {
+			t.Errorf("Got %d links, Expected 3", n)
+		}
+		if dist, ok := g.EdgeDistance("I1", "I2"); !ok || dist != relational.First {
+			t.Errorf("Got distance %d, Expected %d", dist, relational.First)
+		}
+	})
+	t.Run("Rounding is set per graph", func(t *testing.T) {
+		edges := []graph.Edge{{From: "I1", To: "I2", Relatedness: 0.3}}
+		for _, tc := range []struct {
+			rounding util.Rounding
+			nodes    int
+		}{
+			{util.RoundFloor, 2},
+			{util.RoundCeil, 3},
+		} {
+			g := graph.NewGraphFromEdges(edges, graph.Options{MergeReciprocal: true, Rounding: tc.rounding})
+			if n := g.Nodes().Len(); n != tc.nodes {
+				t.Errorf("Got %d nodes by rounding %d, Expected %d", n, tc.rounding, tc.nodes)
+			}
+		}
+	})
+	t.Run("Pairs with equally short paths are ambiguous", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U2", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		ambiguous := g.Ambiguous()
+		if len(ambiguous) != 2 {
+			t.Fatalf("Got %+v, Expected I1 with I2 and I3", ambiguous)
+		}
+		if a := ambiguous[0]; a.ID1 != "I1" || a.ID2 != "I2" || a.Paths != 2 || a.Weight != 2 {
+			t.Errorf("Got %+v, Expected I1 and I2 with two paths of weight 2", a)
+		}
+	})
+	t.Run("Links are tagged with the closest distance through them", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "U2", "I3"}, 1))
+		if d, ok := g.EdgeDistance("U1", "I1"); !ok || d != relational.Second {
+			t.Errorf("Got %v (%t), Expected shared link to be second degree", d, ok)
+		}
+		if d, ok := g.EdgeDistance("U2", "I3"); !ok || d != relational.Third {
+			t.Errorf("Got %v (%t), Expected third degree", d, ok)
+		}
+	})
+	t.Run("Merged reciprocal pairs are linked once", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel\n" +
+			"I1,I2,0.125\n" +
+			"I2,I1,0.125\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{MergeReciprocal: true})
+		if n := g.Nodes().Len(); n != 4 {
+			t.Errorf("Got %d nodes, Expected 4 for a single chain of two unknowns", n)
+		}
+	})
+	t.Run("Carried values are kept on each link", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "relped-*.csv")
+		if err != nil {
+			t.Fatalf("Could not create temporary file: %s", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		f.WriteString("ID1,ID2,Rel,Row\n" +
+			"I1,I2,0.25,r1\n" +
+			"I2,I3,0.5,r2\n")
+		f.Seek(0, 0)
+		in := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true, CarryColumn: "Row"})
+		g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{MergeReciprocal: true})
+		if got := g.Carried("I2", "I3"); len(got) != 1 || got[0] != "r2" {
+			t.Errorf("Got %v, Expected [r2]", got)
+		}
+		nodes := g.FromNamed("I1")
+		for nodes.Next() {
+			name, _ := g.IDToName(nodes.Node().ID())
+			if got := g.Carried(name, "I1"); len(got) != 1 || got[0] != "r1" {
+				t.Errorf("Got %v between I1 and %s, Expected [r1]", got, name)
+			}
+		}
+		ped, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{})
+		if !strings.Contains(ped.String(), `comment="r2"`) {
+			t.Errorf("Expected comment=\"r2\" in:\n%s", ped)
+		}
+	})
+	t.Run("Differences between graphs are found", func(t *testing.T) {
+		from := graph.NewGraph([]string{"I1", "I2", "I3", "I4"})
+		from.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		from.AddPath(graph.NewEqualWeightPath([]string{"I2", "U1", "I3"}, 1))
+		to := graph.NewGraph([]string{"I1", "I2", "I3", "I4"})
+		to.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		to.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		to.AddPath(graph.NewEqualWeightPath([]string{"I4", "V1", "I3"}, 1))
+		changes, same := graph.Diff(from, to)
+		exp := []graph.Change{
+			{ID1: "I1", ID2: "I3", From: 3, To: 2},
+			{ID1: "I1", ID2: "I4", From: 0, To: 4},
+			{ID1: "I2", ID2: "I3", From: 2, To: 1},
+			{ID1: "I2", ID2: "I4", From: 0, To: 3},
+			{ID1: "I3", ID2: "I4", From: 0, To: 2},
+		}
+		if same != 1 {
+			t.Errorf("Got %d unchanged, Expected 1", same)
+		}
+		if len(changes) != len(exp) {
+			t.Fatalf("Got %+v, Expected %+v", changes, exp)
+		}
+		for i := range changes {
+			if changes[i] != exp[i] {
+				t.Errorf("Got %+v, Expected %+v", changes[i], exp[i])
+			}
+		}
+	})
+	t.Run("Differences do not depend on the unknowns drawn", func(t *testing.T) {
+		build := func(dist relational.Degree, maxUnknowns int) *graph.Graph {
+			g := graph.NewGraph([]string{"I1", "I2", "I3"})
+			p, err := graph.NewCappedRelationalWeightPath("I1", "I2", dist, 1, graph.EqualScheme, "", maxUnknowns)
+			if err != nil {
+				t.Fatalf("Could not create path: %s", err)
+			}
+			g.AddPath(p)
+			g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+			return g
+		}
+		if changes, same := graph.Diff(build(relational.Fifth, 0), build(relational.Fifth, 1)); len(changes) != 0 || same != 3 {
+			t.Errorf("Got %+v and %d unchanged, Expected 3 unchanged", changes, same)
+		}
+		changes, _ := graph.Diff(build(relational.Fifth, 1), build(relational.Fourth, 0))
+		if len(changes) != 2 || changes[0] != (graph.Change{ID1: "I1", ID2: "I2", From: 5, To: 4}) {
+			t.Errorf("Got %+v, Expected I1 and I2 to change from 5 to 4", changes)
+		}
+	})
+	t.Run("Seeded graphs are the same every run", func(t *testing.T) {
+		build := func() *graph.Graph {
+			f, err := ioutil.TempFile("", "relped-*.csv")
+			if err != nil {
+				t.Fatalf("Could not create temporary file: %s", err)
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			f.WriteString("ID1,ID2,Rel\n" +
+				"I1,I2,0.25\n" +
+				"I2,I3,0.125\n" +
+				"I1,I3,0.0625\n")
+			f.Seek(0, 0)
+			in := relatedness.NewThreeColumnCsv(f, relatedness.Options{})
+			g := graph.NewGraphFromCsvInput(in, relational.Unrelated, nil, nil, graph.Options{Seed: 42})
+			g.Prune()
+			return g
+		}
+		var first bytes.Buffer
+		if err := build().WriteCache(&first); err != nil {
+			t.Fatalf("Could not write graph: %s", err)
+		}
+		for i := 0; i < 5; i++ {
+			var again bytes.Buffer
+			if err := build().WriteCache(&again); err != nil {
+				t.Fatalf("Could not write graph: %s", err)
+			}
+			if first.String() != again.String() {
+				t.Fatalf("Got differing graphs:\n%s\n%s", first.String(), again.String())
+			}
+		}
+	})
+	t.Run("Seeded pedigrees are byte-identical every run", func(t *testing.T) {
+		edges := []graph.Edge{
+			{From: "I1", To: "I2", Relatedness: 0.25},
+			{From: "I2", To: "I3", Relatedness: 0.125},
+			{From: "I1", To: "I3", Relatedness: 0.0625},
+		}
+		draw := func() string {
+			g := graph.NewGraphFromEdges(edges, graph.Options{MergeReciprocal: true, Seed: 42})
+			g.Prune()
+			p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{})
+			return p.String()
+		}
+		first := draw()
+		for i := 0; i < 5; i++ {
+			if again := draw(); again != first {
+				t.Fatalf("Got differing pedigrees:\n%s\n%s", first, again)
+			}
+		}
+	})
+	t.Run("Seeded unknowns are not named as knowns", func(t *testing.T) {
+		opts := graph.Options{MergeReciprocal: true, Seed: 42}
+		g := graph.NewGraphFromEdges([]graph.Edge{{From: "I1", To: "I2", Relatedness: 0.25}}, opts)
+		var seeded string
+		nodes := g.Nodes()
+		for nodes.Next() {
+			if name, ok := g.IDToName(nodes.Node().ID()); ok && !g.IsKnown(name) {
+				seeded = name
+			}
+		}
+		if seeded == "" {
+			t.Fatalf("Expected an unknown between second degree relatives")
+		}
+		// The known takes the name the same seed first gives an unknown
+		g = graph.NewGraphFromEdges([]graph.Edge{
+			{From: "I1", To: "I2", Relatedness: 0.25},
+			{From: "I1", To: seeded, Relatedness: 0.5},
+		}, opts)
+		if n := g.Nodes().Len(); n != 4 {
+			t.Errorf("Got %d nodes, Expected three knowns and one unknown", n)
+		}
+		if g.HasEdgeBetweenNamed("I2", seeded) {
+			t.Errorf("Expected the unknown between I1 and I2 apart from the known %s", seeded)
+		}
+	})
+}
+
+func BenchmarkPrune(b *testing.B) {
+	indvs := make([]string, 15)
+	for i := range indvs {
+		indvs[i] = "I" + strconv.Itoa(i)
+	}
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		g := graph.NewGraph(indvs)
+		for i := range indvs {
+			for j := i + 1; j < len(indvs); j++ {
+				dist := relational.Degree(1 + (i+j)%4)
+				if p, err := graph.NewRelationalWeightPath(indvs[i], indvs[j], dist, 1, graph.EqualScheme, ""); err == nil {
+					g.AddPath(p)
+				}
+			}
+		}
+		b.StartTimer()
+		g.Prune()
+	}
+}
+
+// BenchmarkWeightCache compares the searches pruning makes with edge
+// weights cached once against looking each up as it is visited, on the
+// graph BenchmarkPrune prunes
+func BenchmarkWeightCache(b *testing.B) {
+	indvs := make([]string, 15)
+	for i := range indvs {
+		indvs[i] = "I" + strconv.Itoa(i)
+	}
+	g := graph.NewGraph(indvs)
+	for i := range indvs {
+		for j := i + 1; j < len(indvs); j++ {
+			dist := relational.Degree(1 + (i+j)%4)
+			if p, err := graph.NewRelationalWeightPath(indvs[i], indvs[j], dist, 1, graph.EqualScheme, ""); err == nil {
+				g.AddPath(p)
+			}
+		}
+	}
+	b.Run("cached", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			graph.SearchKnowns(g, true)
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			graph.SearchKnowns(g, false)
+		}
+	})
+}
+
+// BenchmarkShortestFrom compares the single-source searches
+// pruning may use, on the graph BenchmarkPrune prunes
+func BenchmarkShortestFrom(b *testing.B) {
+	indvs := make([]string, 15)
+	for i := range indvs {
+		indvs[i] = "I" + strconv.Itoa(i)
+	}
+	g := graph.NewGraph(indvs)
+	for i := range indvs {
+		for j := i + 1; j < len(indvs); j++ {
+			dist := relational.Degree(1 + (i+j)%4)
+			if p, err := graph.NewRelationalWeightPath(indvs[i], indvs[j], dist, 1, graph.EqualScheme, ""); err == nil {
+				g.AddPath(p)
+			}
+		}
+	}
+	b.Run("Dijkstra", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for _, indv := range indvs {
+				path.DijkstraFrom(g.NodeNamed(indv), g)
+			}
+		}
+	})
+	b.Run("BellmanFord", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for _, indv := range indvs {
+				path.BellmanFordFrom(g.NodeNamed(indv), g)
+			}
+		}
+	})
 }
@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rhagenson/relped/internal/unit"
+	gonumGraph "gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/path"
+)
+
+// Imputed is the relatedness estimated for a pair of knowns
+// that was not observed in the input
+type Imputed struct {
+	ID1, ID2    string
+	Distance    uint
+	Relatedness unit.Relatedness
+}
+
+// Impute estimates relatedness for every connected pair of knowns not in
+// observed by halving relatedness once for each generation along the
+// shortest path between them, leaving out pairs joined through a bridge
+func (graph *Graph) Impute(observed [][2]string) []Imputed {
+	seen := make(map[[2]string]bool, len(observed))
+	for _, pair := range observed {
+		seen[[2]string{pair[0], pair[1]}] = true
+		seen[[2]string{pair[1], pair[0]}] = true
+	}
+
+	var imputed []Imputed
+	graph.eachKnownPath(func(from, to string, nodes []gonumGraph.Node, _ float64) {
+		if seen[[2]string{from, to}] {
+			return
+		}
+		dist, ok := graph.pathDistance(nodes)
+		if !ok {
+			return // Bridges are assumed, so imply no relatedness
+		}
+		imputed = append(imputed, Imputed{
+			ID1:         from,
+			ID2:         to,
+			Distance:    dist,
+			Relatedness: unit.Relatedness(math.Pow(0.5, float64(dist))),
+		})
+	})
+	return imputed
+}
+
+// eachKnownPath calls fn with the shortest path between each connected
+// pair of knowns, visiting pairs in sorted order
+func (graph *Graph) eachKnownPath(fn func(from, to string, nodes []gonumGraph.Node, weight float64)) {
+	indvs := make([]string, len(graph.knowns))
+	copy(indvs, graph.knowns)
+	sort.Strings(indvs)
+
+	for i := range indvs {
+		src := graph.NodeNamed(indvs[i])
+		if src == nil {
+			continue
+		}
+		shortest := path.DijkstraFrom(src, graph)
+		for j := i + 1; j < len(indvs); j++ {
+			if dest := graph.NodeNamed(indvs[j]); dest != nil {
+				if nodes, weight := shortest.To(dest.ID()); 1 < len(nodes) {
+					fn(indvs[i], indvs[j], nodes, weight)
+				}
+			}
+		}
+	}
+}
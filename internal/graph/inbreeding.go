@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"math"
+	"sort"
+)
+
+// Inbred is the estimated inbreeding coefficient of a known
+type Inbred struct {
+	ID string
+	F  float64
+}
+
+// Inbreeding estimates the inbreeding coefficient of every known
+//
+// Following Wright, each loop closed through an individual contributes
+// 0.5^(n+1), where n is the number of links between its two neighbors
+// without passing through the individual itself. The pedigree is inferred,
+// so neighbors stand in for parents, only the shortest loop per pair of
+// neighbors is counted, and the inbreeding of shared ancestors is ignored.
+func (graph *Graph) Inbreeding() []Inbred {
+	indvs := make([]string, len(graph.knowns))
+	copy(indvs, graph.knowns)
+	sort.Strings(indvs)
+
+	inbred := make([]Inbred, 0, len(indvs))
+	for _, indv := range indvs {
+		id, ok := graph.NameToID(indv)
+		if !ok || graph.Node(id) == nil {
+			continue
+		}
+		var neighbors []int64
+		nodes := graph.From(id)
+		for nodes.Next() {
+			neighbors = append(neighbors, nodes.Node().ID())
+		}
+		sort.Slice(neighbors, func(i, j int) bool { return neighbors[i] < neighbors[j] })
+
+		f := 0.0
+		for i := range neighbors {
+			hops := graph.hopsAvoiding(neighbors[i], id)
+			for j := i + 1; j < len(neighbors); j++ {
+				if n, ok := hops[neighbors[j]]; ok {
+					f += math.Pow(0.5, float64(n+1))
+				}
+			}
+		}
+		inbred = append(inbred, Inbred{ID: indv, F: math.Min(f, 1)})
+	}
+	return inbred
+}
+
+// hopsAvoiding counts the links from src to every node reachable
+// without passing through the avoided node
+func (graph *Graph) hopsAvoiding(src, avoid int64) map[int64]int {
+	hops := map[int64]int{src: 0}
+	queue := []int64{src}
+	for len(queue) != 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		nodes := graph.From(cur)
+		for nodes.Next() {
+			next := nodes.Node().ID()
+			if _, seen := hops[next]; seen || next == avoid {
+				continue
+			}
+			hops[next] = hops[cur] + 1
+			queue = append(queue, next)
+		}
+	}
+	return hops
+}
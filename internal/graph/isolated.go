@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// RmIsolatedUnknowns removes unknowns that cannot lie on a path between two
+// knowns: dead-end branches of unknowns and groups of only unknowns
+func (graph *Graph) RmIsolatedUnknowns() {
+	for removed := true; removed; {
+		removed = false
+		for name := range graph.nameToInfo {
+			if graph.IsKnown(name) || graph.NodeNamed(name) == nil {
+				continue
+			}
+			if graph.FromNamed(name).Len() <= 1 {
+				graph.RemoveNodeNamed(name)
+				removed = true
+			}
+		}
+	}
+
+	for _, component := range topo.ConnectedComponents(graph) {
+		hasKnown := false
+		for _, node := range component {
+			if name, ok := graph.IDToName(node.ID()); ok && graph.IsKnown(name) {
+				hasKnown = true
+				break
+			}
+		}
+		if !hasKnown {
+			for _, node := range component {
+				if name, ok := graph.IDToName(node.ID()); ok {
+					graph.RemoveNodeNamed(name)
+				}
+			}
+		}
+	}
+}
+
+// RmSmallComponents removes connected components with fewer than min known
+// individuals, returning the number of components and knowns removed
+func (graph *Graph) RmSmallComponents(min int) (components, knowns int) {
+	for _, component := range topo.ConnectedComponents(graph) {
+		var names []string
+		known := 0
+		for _, node := range component {
+			if name, ok := graph.IDToName(node.ID()); ok {
+				names = append(names, name)
+				if graph.IsKnown(name) {
+					known++
+				}
+			}
+		}
+		if known < min {
+			for _, name := range names {
+				graph.RemoveNodeNamed(name)
+			}
+			components++
+			knowns += known
+		}
+	}
+	return components, knowns
+}
+
+// RmDisconnected removes individuals without any links, returning how many
+// Pruning removes nodes but keeps their information, which this drops too
+func (graph *Graph) RmDisconnected() int {
+	n := 0
+	for name := range graph.nameToInfo {
+		if graph.FromNamed(name).Len() != 0 {
+			continue
+		}
+		if graph.NodeNamed(name) != nil {
+			n++
+		}
+		graph.RemoveNodeNamed(name)
+	}
+	return n
+}
@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"sort"
+)
+
+// KeepLightest removes all but the max lightest links, the strongest
+// relationships, then any unknowns left unable to link two knowns,
+// returning the number of links removed
+func (graph *Graph) KeepLightest(max int) int {
+	before := graph.Edges().Len()
+	if before <= max {
+		return 0
+	}
+
+	type link struct {
+		ids    [2]int64
+		names  [2]string
+		weight float64
+	}
+	var links []link
+	edges := graph.WeightedEdges()
+	for edges.Next() {
+		e := edges.WeightedEdge()
+		x, y := e.From().ID(), e.To().ID()
+		n1, _ := graph.IDToName(x)
+		n2, _ := graph.IDToName(y)
+		if n2 < n1 {
+			n1, n2 = n2, n1
+		}
+		links = append(links, link{[2]int64{x, y}, [2]string{n1, n2}, e.Weight()})
+	}
+	sort.Slice(links, func(i, j int) bool {
+		switch {
+		case links[i].weight != links[j].weight:
+			return links[i].weight < links[j].weight
+		case links[i].names[0] != links[j].names[0]:
+			return links[i].names[0] < links[j].names[0]
+		default:
+			return links[i].names[1] < links[j].names[1]
+		}
+	})
+	for _, l := range links[max:] {
+		graph.RemoveEdge(l.ids[0], l.ids[1])
+	}
+	graph.RmIsolatedUnknowns()
+	return before - graph.Edges().Len()
+}
@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"sort"
+
+	gonumGraph "gonum.org/v1/gonum/graph"
+)
+
+// Distances is the relational distance along the shortest path between
+// every pair of knowns, in the order of Names, with zero for pairs not
+// connected or connected only through a bridge, which has no distance
+func (graph *Graph) Distances() (names []string, dists [][]uint) {
+	names = make([]string, len(graph.knowns))
+	copy(names, graph.knowns)
+	sort.Strings(names)
+
+	index := make(map[string]int, len(names))
+	dists = make([][]uint, len(names))
+	for i, name := range names {
+		index[name] = i
+		dists[i] = make([]uint, len(names))
+	}
+	graph.eachKnownPath(func(from, to string, nodes []gonumGraph.Node, _ float64) {
+		i, j := index[from], index[to]
+		dists[i][j], _ = graph.pathDistance(nodes)
+		dists[j][i] = dists[i][j]
+	})
+	return names, dists
+}
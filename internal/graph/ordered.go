@@ -0,0 +1,28 @@
+package graph
+
+import (
+	"sort"
+
+	gonumGraph "gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/iterator"
+)
+
+// orderedGraph is a Graph visiting nodes in order of ID, so searches
+// that depend on visiting order give the same result every run
+type orderedGraph struct {
+	*Graph
+}
+
+func (g orderedGraph) Nodes() gonumGraph.Nodes {
+	return sortedNodes(g.Graph.Nodes())
+}
+
+func (g orderedGraph) From(id int64) gonumGraph.Nodes {
+	return sortedNodes(g.Graph.From(id))
+}
+
+func sortedNodes(it gonumGraph.Nodes) gonumGraph.Nodes {
+	nodes := gonumGraph.NodesOf(it)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+	return iterator.NewOrderedNodes(nodes)
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/rhagenson/relped/internal/unit"
 	"github.com/rhagenson/relped/internal/unit/relational"
@@ -56,23 +57,192 @@ func NewFractionalWeightPath(names []string, weight unit.Weight) *FractionalWeig
 	return &FractionalWeightPath{names, weight}
 }
 
+// GeometricWeightPath halves the weight with each link away from the first name,
+// following the halving of relatedness with each generation
+type GeometricWeightPath struct {
+	names  []string
+	weight unit.Weight
+}
+
+func (p GeometricWeightPath) Names() []string {
+	return p.names
+}
+
+func (p GeometricWeightPath) Weights() []unit.Weight {
+	weights := make([]unit.Weight, len(p.names)-1)
+	total := 0.0
+	for i := range weights {
+		total += math.Pow(0.5, float64(i))
+	}
+	for i := range weights {
+		weights[i] = unit.Weight(float64(p.weight) * math.Pow(0.5, float64(i)) / total)
+	}
+	return weights
+}
+
+func NewGeometricWeightPath(names []string, weight unit.Weight) *GeometricWeightPath {
+	return &GeometricWeightPath{names, weight}
+}
+
+// endpointsShare is the fraction of weight an EndpointsWeightPath
+// places on the two links touching its ends
+const endpointsShare = 0.8
+
+// EndpointsWeightPath concentrates weight on the links touching its ends,
+// which are the only links supported by observed individuals
+type EndpointsWeightPath struct {
+	names  []string
+	weight unit.Weight
+}
+
+func (p EndpointsWeightPath) Names() []string {
+	return p.names
+}
+
+func (p EndpointsWeightPath) Weights() []unit.Weight {
+	weights := make([]unit.Weight, len(p.names)-1)
+	if len(weights) <= 2 {
+		return NewFractionalWeightPath(p.names, p.weight).Weights()
+	}
+	ends := float64(p.weight) * endpointsShare / 2
+	inner := float64(p.weight) * (1 - endpointsShare) / float64(len(weights)-2)
+	for i := range weights {
+		if i == 0 || i == len(weights)-1 {
+			weights[i] = unit.Weight(ends)
+		} else {
+			weights[i] = unit.Weight(inner)
+		}
+	}
+	return weights
+}
+
+func NewEndpointsWeightPath(names []string, weight unit.Weight) *EndpointsWeightPath {
+	return &EndpointsWeightPath{names, weight}
+}
+
+// WeightScheme is how the weight of a relationship is spread
+// across the links in its chain of unknowns
+type WeightScheme uint
+
+const (
+	// EqualScheme splits weight evenly, making no assumption
+	// about where in the chain uncertainty lies
+	EqualScheme WeightScheme = iota
+	// GeometricScheme decays weight along generational links
+	// as relatedness is expected to halve each generation
+	GeometricScheme
+	// EndpointsScheme concentrates weight near the knowns as
+	// the unknowns between them are inferred, not observed
+	EndpointsScheme
+)
+
+// ParseWeightScheme converts the name of a scheme to its WeightScheme
+func ParseWeightScheme(name string) (WeightScheme, error) {
+	switch name {
+	case "equal":
+		return EqualScheme, nil
+	case "geometric":
+		return GeometricScheme, nil
+	case "endpoints":
+		return EndpointsScheme, nil
+	default:
+		return EqualScheme, fmt.Errorf("unknown weight scheme %q, use one of: equal, geometric, endpoints", name)
+	}
+}
+
+// DistanceWeight is the weight of a relationship as the number of
+// generations its relatedness implies, plus one to stay positive:
+// 2 at relatedness 0.5, 3 at 0.25, and 4 at 0.125
+// Unlike Relatedness.Weight, which doubles with each generation,
+// this grows by one, so path costs add up like relational distance
+func DistanceWeight(r unit.Relatedness) unit.Weight {
+	if 1 < r {
+		r = 1
+	}
+	return unit.Weight(1 - math.Log2(float64(r)))
+}
+
+// MarkerWeight scales weight by the square root of how many times fewer
+// than max markers n is, as the error of relatedness estimates shrinks
+// with the square root of the markers used
+func MarkerWeight(weight unit.Weight, n, max uint) unit.Weight {
+	if n == 0 || max <= n {
+		return weight
+	}
+	return weight * unit.Weight(math.Sqrt(float64(max)/float64(n)))
+}
+
 type RelationalWeightPath struct {
-	p *FractionalWeightPath
+	p       Path
+	dist    relational.Degree
+	carried string
+	input   unit.Relatedness
 }
 
 func (p RelationalWeightPath) Names() []string {
-	return p.p.names
+	return p.p.Names()
 }
 
 func (p RelationalWeightPath) Weights() []unit.Weight {
 	return p.p.Weights()
 }
 
-func NewRelationalWeightPath(from, to string, dist relational.Degree, weight unit.Weight) (*RelationalWeightPath, error) {
+// Distance is the relational distance the path was drawn for,
+// which is more than its number of links when capped
+func (p RelationalWeightPath) Distance() relational.Degree {
+	return p.dist
+}
+
+// Carried is the value kept from the input row the path was drawn for
+func (p RelationalWeightPath) Carried() string {
+	return p.carried
+}
+
+// Input is the relatedness, as read, the path was drawn for
+func (p RelationalWeightPath) Input() unit.Relatedness {
+	return p.input
+}
+
+// NewRelationalWeightPath links from and to through dist-1 unknowns,
+// each named with prefix followed by a unique suffix
+func NewRelationalWeightPath(from, to string, dist relational.Degree, weight unit.Weight, scheme WeightScheme, prefix string) (*RelationalWeightPath, error) {
+	return NewCappedRelationalWeightPath(from, to, dist, weight, scheme, prefix, 0)
+}
+
+// NewCappedRelationalWeightPath is NewRelationalWeightPath drawing at most
+// maxUnknowns unknowns, with the last link standing in for the rest
+// of the relationship; zero maxUnknowns draws every unknown
+func NewCappedRelationalWeightPath(from, to string, dist relational.Degree, weight unit.Weight, scheme WeightScheme, prefix string, maxUnknowns int) (*RelationalWeightPath, error) {
+	return newRelationalWeightPath(from, to, dist, weight, scheme, maxUnknowns, xidNamer(prefix))
+}
+
+// xidNamer names unknowns with prefix followed by part of a new xid
+func xidNamer(prefix string) func() string {
+	return func() string {
+		name := xid.New().String()
+		return prefix + name[len(name)-lenUnknownNames:]
+	}
+}
+
+// chainLinks is the number of links drawn for a relationship at dist,
+// with at most maxUnknowns unknowns; zero maxUnknowns draws every unknown
+func chainLinks(dist relational.Degree, maxUnknowns int) int {
+	links := int(dist)
+	if 0 < maxUnknowns && maxUnknowns+1 < links {
+		links = maxUnknowns + 1
+	}
+	return links
+}
+
+func newRelationalWeightPath(from, to string, dist relational.Degree, weight unit.Weight, scheme WeightScheme, maxUnknowns int, newName func() string) (*RelationalWeightPath, error) {
 	if dist == relational.Unrelated {
 		return nil, fmt.Errorf("%q and %q are unrelated, no path possible", from, to)
 	}
-	names := make([]string, dist+1)
+	if relational.Ninth < dist {
+		return nil, fmt.Errorf("%q and %q are %d degrees apart, beyond the furthest estimable of %d", from, to, dist, relational.Ninth)
+	}
+	links := chainLinks(dist, maxUnknowns)
+	names := make([]string, links+1)
 	// Add knowns
 	names[0] = from
 	names[len(names)-1] = to
@@ -80,9 +250,15 @@ func NewRelationalWeightPath(from, to string, dist relational.Degree, weight uni
 		if i == 0 || i == len(names)-1 {
 			continue
 		} else {
-			name := xid.New().String()
-			names[i] = name[len(name)-lenUnknownNames:]
+			names[i] = newName()
 		}
 	}
-	return &RelationalWeightPath{&FractionalWeightPath{names, weight}}, nil
+	switch scheme {
+	case GeometricScheme:
+		return &RelationalWeightPath{p: NewGeometricWeightPath(names, weight), dist: dist}, nil
+	case EndpointsScheme:
+		return &RelationalWeightPath{p: NewEndpointsWeightPath(names, weight), dist: dist}, nil
+	default:
+		return &RelationalWeightPath{p: NewFractionalWeightPath(names, weight), dist: dist}, nil
+	}
 }
@@ -0,0 +1,123 @@
+package graph_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/unit"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+func TestWeightSchemes(t *testing.T) {
+	tt := []struct {
+		name   string
+		scheme graph.WeightScheme
+		exp    []unit.Weight
+	}{
+		{
+			name:   "Equal splits weight evenly",
+			scheme: graph.EqualScheme,
+			exp:    []unit.Weight{2, 2, 2, 2},
+		},
+		{
+			name:   "Geometric halves weight each link",
+			scheme: graph.GeometricScheme,
+			exp:    []unit.Weight{4.266666, 2.133333, 1.066666, 0.533333},
+		},
+		{
+			name:   "Endpoints concentrates weight on the ends",
+			scheme: graph.EndpointsScheme,
+			exp:    []unit.Weight{3.2, 0.8, 0.8, 3.2},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := graph.NewRelationalWeightPath("I1", "I2", relational.Fourth, 8, tc.scheme, "")
+			if err != nil {
+				t.Fatalf("Could not create path: %s", err)
+			}
+			got := p.Weights()
+			if len(got) != len(tc.exp) {
+				t.Fatalf("Got %d weights, Expected %d", len(got), len(tc.exp))
+			}
+			total := 0.0
+			for i := range got {
+				total += float64(got[i])
+				if 1e-5 < math.Abs(float64(got[i]-tc.exp[i])) {
+					t.Errorf("Got %v, Expected %v", got, tc.exp)
+					break
+				}
+			}
+			if 1e-9 < math.Abs(total-8) {
+				t.Errorf("Weights sum to %f, Expected %f", total, 8.0)
+			}
+		})
+	}
+}
+
+func TestDistanceWeight(t *testing.T) {
+	tt := []struct {
+		rel unit.Relatedness
+		exp unit.Weight
+	}{
+		{1, 1},
+		{0.5, 2},
+		{0.25, 3},
+		{0.125, 4},
+		{1.5, 1},
+	}
+	for _, tc := range tt {
+		if got := graph.DistanceWeight(tc.rel); 1e-9 < math.Abs(float64(got-tc.exp)) {
+			t.Errorf("Got %v for %v, Expected %v", got, tc.rel, tc.exp)
+		}
+	}
+}
+
+func TestMarkerWeight(t *testing.T) {
+	tt := []struct {
+		n, max uint
+		exp    unit.Weight
+	}{
+		{400, 400, 2},
+		{100, 400, 4},
+		{25, 400, 8},
+		{0, 400, 2},
+	}
+	for _, tc := range tt {
+		if got := graph.MarkerWeight(2, tc.n, tc.max); 1e-9 < math.Abs(float64(got-tc.exp)) {
+			t.Errorf("Got %v for %d of %d markers, Expected %v", got, tc.n, tc.max, tc.exp)
+		}
+	}
+}
+
+func TestCappedRelationalWeightPath(t *testing.T) {
+	p, err := graph.NewCappedRelationalWeightPath("I1", "I2", relational.Fifth, 10, graph.EqualScheme, "", 2)
+	if err != nil {
+		t.Fatalf("Could not create path: %s", err)
+	}
+	if n := len(p.Names()); n != 4 {
+		t.Errorf("Got %d names, Expected two knowns and two unknowns", n)
+	}
+	if d := p.Distance(); d != relational.Fifth {
+		t.Errorf("Got distance %v, Expected %v", d, relational.Fifth)
+	}
+	g := graph.NewGraph([]string{"I1", "I2"})
+	g.AddPath(p)
+	names := p.Names()
+	if !g.IsSummarized(names[2], "I2") || g.IsSummarized("I1", names[1]) {
+		t.Errorf("Expected only the last link to be summarized")
+	}
+}
+
+func TestRelationalWeightPathLimits(t *testing.T) {
+	for _, dist := range []relational.Degree{relational.Unrelated, relational.Ninth + 1, 30} {
+		if _, err := graph.NewRelationalWeightPath("I1", "I2", dist, 1, graph.EqualScheme, ""); err == nil {
+			t.Errorf("Expected an error for a path of distance %d", dist)
+		}
+	}
+	if _, err := graph.NewRelationalWeightPath("I1", "I2", relational.Ninth, 1, graph.EqualScheme, ""); err != nil {
+		t.Errorf("Expected a path of distance %d: %s", relational.Ninth, err)
+	}
+}
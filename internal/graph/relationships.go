@@ -0,0 +1,33 @@
+package graph
+
+import (
+	gonumGraph "gonum.org/v1/gonum/graph"
+)
+
+// Relationship is how two knowns are connected in the pedigree
+type Relationship struct {
+	ID1, ID2 string
+	Distance uint    // Relational distance along the shortest path, zero through a bridge
+	Weight   float64 // Total weight along the shortest path
+}
+
+// Relationships lists every pair of knowns connected within maxDist,
+// in sorted order, with zero for no limit
+// Pairs connected through a bridge have no distance, so are left out
+// under a limit
+func (graph *Graph) Relationships(maxDist uint) []Relationship {
+	var rels []Relationship
+	graph.eachKnownPath(func(from, to string, nodes []gonumGraph.Node, weight float64) {
+		dist, ok := graph.pathDistance(nodes)
+		if maxDist != 0 && (!ok || maxDist < dist) {
+			return
+		}
+		rels = append(rels, Relationship{
+			ID1:      from,
+			ID2:      to,
+			Distance: dist,
+			Weight:   weight,
+		})
+	})
+	return rels
+}
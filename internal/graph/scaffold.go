@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+// addCategoryScaffold links from and to by the shape of their relationship
+// category, reporting false when in has no category with a shape for them
+//
+// Full siblings share two unknown parents, where a chain would give one.
+// Other categories are already the right shape as a chain: parent-offspring
+// are linked directly and half siblings share one unknown parent.
+func (graph *Graph) addCategoryScaffold(in relatedness.CsvInput, from, to string, weight unit.Weight, carried string, opts Options) bool {
+	cats, ok := in.(relatedness.CategoryInput)
+	if !ok {
+		return false
+	}
+	cat, ok := cats.Category(from, to)
+	if !ok || cat != "FS" {
+		return false
+	}
+	if to < from {
+		return true // Shared parents are added once per pair
+	}
+	for parent := 0; parent < 2; parent++ {
+		path, err := newRelationalWeightPath(from, to, relational.Second, weight, opts.Scheme, 0, graph.unknownNamer(opts.UnknownPrefix))
+		if err != nil {
+			return false
+		}
+		path.carried = carried
+		path.input = in.Relatedness(from, to)
+		graph.AddPath(path)
+		if id, ok := graph.NameToID(path.Names()[1]); ok {
+			graph.scaffold[id] = true
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+// sharedParent finds an unknown linked to known, and to at least one
+// other known, where every other known it links is at dist from other in
+// the input, as siblings of known through the unknown would be when other
+// descends from it too. Ties are broken by name.
+// Returns false if no unknown fits.
+func (graph *Graph) sharedParent(in relatedness.CsvInput, known, other string, dist relational.Degree) (string, bool) {
+	var candidates []string
+	nodes := graph.FromNamed(known)
+	for nodes.Next() {
+		parent, ok := graph.IDToName(nodes.Node().ID())
+		if !ok || graph.IsKnown(parent) {
+			continue
+		}
+		siblings := 0
+		fits := true
+		children := graph.FromNamed(parent)
+		for children.Next() && fits {
+			child, ok := graph.IDToName(children.Node().ID())
+			if !ok || child == known || child == other || !graph.IsKnown(child) {
+				continue
+			}
+			siblings++
+			fits = in.RelDistance(child, other) == dist
+		}
+		if fits && 0 < siblings {
+			candidates = append(candidates, parent)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	sort.Strings(candidates)
+	return candidates[0], true
+}
+
+// sharingNamer names the n unknowns of a path as newName does, except
+// the first is named first and the last is named last, when not empty,
+// so the path runs through unknowns already in the graph
+func sharingNamer(first, last string, n int, newName func() string) func() string {
+	i := 0
+	return func() string {
+		i++
+		switch {
+		case i == 1 && first != "":
+			return first
+		case i == n && last != "":
+			return last
+		}
+		return newName()
+	}
+}
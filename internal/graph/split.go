@@ -0,0 +1,124 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/rhagenson/relped/internal/io/demographics"
+	"github.com/rhagenson/relped/internal/io/parentage"
+	"github.com/rhagenson/relped/internal/util"
+	gonumGraph "gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// splitValue is the information field of name given by the inputs,
+// before it is added to any graph, or empty if not given
+func splitValue(name, field string, pars parentage.CsvInput, dems demographics.CsvInput, delim string) string {
+	var info Info
+	switch field {
+	case "family":
+		info.Family, _ = util.SplitID(name, delim)
+	case "sex":
+		if dems != nil {
+			info.Sex, _ = dems.Sex(name)
+		}
+	case "age":
+		if dems != nil {
+			info.Age, _ = dems.Age(name)
+		}
+	case "sire":
+		if pars != nil {
+			info.Sire, _ = pars.Sire(name)
+		}
+	case "dam":
+		if pars != nil {
+			info.Dam, _ = pars.Dam(name)
+		}
+	}
+	val, _ := info.Field(field)
+	return val
+}
+
+// Partitions groups the knowns in the graph by the named information,
+// with those missing it grouped under the empty string
+func (graph *Graph) Partitions(field string) map[string][]string {
+	parts := make(map[string][]string)
+	for _, name := range graph.knowns {
+		if graph.NodeNamed(name) == nil {
+			continue
+		}
+		val, _ := graph.Info(name).Field(field)
+		parts[val] = append(parts[val], name)
+	}
+	for _, names := range parts {
+		sort.Strings(names)
+	}
+	return parts
+}
+
+// Subgraph copies the knowns and every individual connected to them,
+// along with the links between them, keeping the same node IDs
+func (graph *Graph) Subgraph(knowns []string) *Graph {
+	sub := NewGraph(knowns)
+	sub.stableIDs = graph.stableIDs
+	sub.threads = graph.threads
+	seen := make(map[int64]bool)
+	var queue []gonumGraph.Node
+	for _, name := range knowns {
+		if n := graph.NodeNamed(name); n != nil && !seen[n.ID()] {
+			seen[n.ID()] = true
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) != 0 {
+		n := queue[0]
+		queue = queue[1:]
+		name, _ := graph.IDToName(n.ID())
+		sub.AddNode(simple.Node(n.ID()))
+		sub.AddInfo(name, graph.Info(name))
+		if graph.scaffold[n.ID()] {
+			sub.scaffold[n.ID()] = true
+		}
+		to := graph.From(n.ID())
+		for to.Next() {
+			if !seen[to.Node().ID()] {
+				seen[to.Node().ID()] = true
+				queue = append(queue, to.Node())
+			}
+		}
+	}
+	edges := graph.WeightedEdges()
+	for edges.Next() {
+		e := edges.WeightedEdge()
+		from, to := e.From().ID(), e.To().ID()
+		if !seen[from] || !seen[to] {
+			continue
+		}
+		sub.SetWeightedEdge(sub.NewWeightedEdge(sub.Node(from), sub.Node(to), e.Weight()))
+		key := [2]int64{from, to}
+		if to < from {
+			key = [2]int64{to, from}
+		}
+		if dist, ok := graph.edgeDists[key]; ok {
+			sub.edgeDists[key] = dist
+		}
+		if span, ok := graph.summarized[key]; ok {
+			sub.summarized[key] = span
+		}
+		if graph.bridged[key] {
+			sub.bridged[key] = true
+		}
+		if carried, ok := graph.carried[key]; ok {
+			sub.carried[key] = carried
+		}
+		if support, ok := graph.support[key]; ok {
+			sub.support[key] = support
+		}
+		if traces, ok := graph.traces[key]; ok {
+			if sub.traces == nil {
+				sub.traces = make(map[[2]int64][]Trace)
+			}
+			sub.traces[key] = traces
+		}
+	}
+	return sub
+}
@@ -0,0 +1,37 @@
+package graph
+
+import (
+	"github.com/rhagenson/relped/internal/unit"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+// Trace is a relationship from the input drawn through a link
+type Trace struct {
+	ID1, ID2    string            // Knowns whose relationship was drawn
+	Relatedness unit.Relatedness  // As read, before Options.RelatednessPower
+	Distance    relational.Degree // Relational distance of the relationship
+	Weight      unit.Weight       // Given to the link by the relationship
+}
+
+// trace records t for the link between x and y
+func (graph *Graph) trace(x, y int64, t Trace) {
+	if y < x {
+		x, y = y, x
+	}
+	graph.traces[[2]int64{x, y}] = append(graph.traces[[2]int64{x, y}], t)
+}
+
+// Traces are the relationships drawn through the link between n1 and n2,
+// in the order they were added, when built with Options.Trace
+// Links added other than from relatedness, such as parentage, have none
+func (graph *Graph) Traces(n1, n2 string) []Trace {
+	x, xOk := graph.NameToID(n1)
+	y, yOk := graph.NameToID(n2)
+	if !xOk || !yOk {
+		return nil
+	}
+	if y < x {
+		x, y = y, x
+	}
+	return graph.traces[[2]int64{x, y}]
+}
@@ -0,0 +1,48 @@
+package graph
+
+import "sort"
+
+// Unknown is an inferred individual and the knowns it links
+type Unknown struct {
+	ID     string
+	Knowns []string
+}
+
+// Unknowns lists every unknown in the graph with the knowns reached from
+// it through other unknowns alone, the individuals it is inferred to link.
+// These are ancestors the pedigree implies but which were not sampled.
+func (graph *Graph) Unknowns() []Unknown {
+	var names []string
+	for name := range graph.nameToInfo {
+		if !graph.IsKnown(name) && graph.NodeNamed(name) != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	unknowns := make([]Unknown, 0, len(names))
+	for _, name := range names {
+		var knowns []string
+		seen := map[string]bool{name: true}
+		queue := []string{name}
+		for len(queue) != 0 {
+			nodes := graph.FromNamed(queue[0])
+			queue = queue[1:]
+			for nodes.Next() {
+				next, ok := graph.IDToName(nodes.Node().ID())
+				if !ok || seen[next] {
+					continue
+				}
+				seen[next] = true
+				if graph.IsKnown(next) {
+					knowns = append(knowns, next)
+				} else {
+					queue = append(queue, next)
+				}
+			}
+		}
+		sort.Strings(knowns)
+		unknowns = append(unknowns, Unknown{ID: name, Knowns: knowns})
+	}
+	return unknowns
+}
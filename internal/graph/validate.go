@@ -0,0 +1,56 @@
+package graph
+
+import "sort"
+
+// Invalid is a known linked to more unknown parents than is possible
+type Invalid struct {
+	ID       string
+	Unknowns []string
+}
+
+// Validate finds every known linked directly to more than two unknown
+// parents, which is biologically impossible and indicates conflicting
+// relatedness or over-eager inference of unknowns
+// Unknowns are parents when added as such for a relationship category,
+// or when shared with another known, as half siblings share a parent.
+// Other unknowns start chains to more distant relatives, which may run
+// up or down generations, so are not counted.
+func (graph *Graph) Validate() []Invalid {
+	indvs := make([]string, len(graph.knowns))
+	copy(indvs, graph.knowns)
+	sort.Strings(indvs)
+
+	var invalid []Invalid
+	for _, indv := range indvs {
+		var unknowns []string
+		nodes := graph.FromNamed(indv)
+		for nodes.Next() {
+			if name, ok := graph.IDToName(nodes.Node().ID()); ok && graph.isParentOf(name, indv) {
+				unknowns = append(unknowns, name)
+			}
+		}
+		if 2 < len(unknowns) {
+			sort.Strings(unknowns)
+			invalid = append(invalid, Invalid{ID: indv, Unknowns: unknowns})
+		}
+	}
+	return invalid
+}
+
+// isParentOf reports whether name is an unknown parent of the known indv,
+// as a scaffold parent or one linked directly to another known
+func (graph *Graph) isParentOf(name, indv string) bool {
+	if graph.IsKnown(name) {
+		return false
+	}
+	if id, ok := graph.NameToID(name); ok && graph.scaffold[id] {
+		return true
+	}
+	nodes := graph.FromNamed(name)
+	for nodes.Next() {
+		if other, ok := graph.IDToName(nodes.Node().ID()); ok && other != indv && graph.IsKnown(other) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package graph
+
+import (
+	gonumGraph "gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/path"
+)
+
+// weightCache is a Graph with every edge weight looked up once,
+// sparing repeated lookups in shortest path searches
+type weightCache struct {
+	*Graph
+	weights  map[[2]int64]float64
+	negative bool // Whether any weight is negative, ruling out Dijkstra
+}
+
+// cacheWeights snapshots the current edge weights of graph
+// The cache must not be used after edges change
+func (graph *Graph) cacheWeights() weightCache {
+	c := weightCache{
+		Graph:   graph,
+		weights: make(map[[2]int64]float64, graph.WeightedEdges().Len()),
+	}
+	edges := graph.WeightedEdges()
+	for edges.Next() {
+		e := edges.WeightedEdge()
+		from, to := e.From().ID(), e.To().ID()
+		c.weights[[2]int64{from, to}] = e.Weight()
+		c.weights[[2]int64{to, from}] = e.Weight()
+		c.negative = c.negative || e.Weight() < 0
+	}
+	return c
+}
+
+func (c weightCache) Weight(xid, yid int64) (w float64, ok bool) {
+	if w, ok := c.weights[[2]int64{xid, yid}]; ok {
+		return w, true
+	}
+	return c.Graph.Weight(xid, yid)
+}
+
+// From visits neighbours in order of ID, so searches break ties
+// between equally short paths the same way every run
+func (c weightCache) From(id int64) gonumGraph.Nodes {
+	return sortedNodes(c.Graph.From(id))
+}
+
+// shortestFrom finds the shortest paths from src to every node in one
+// search, by Dijkstra unless negative weights require Bellman-Ford
+// Returns false if there is a negative cycle
+func (c weightCache) shortestFrom(src gonumGraph.Node) (path.Shortest, bool) {
+	if c.negative {
+		return path.BellmanFordFrom(src, c)
+	}
+	return path.DijkstraFrom(src, c), true
+}
@@ -0,0 +1,251 @@
+// Package html writes a pedigree graph as a self-contained interactive page
+package html
+
+import (
+	"html/template"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/util"
+)
+
+type page struct {
+	Nodes []node `json:"nodes"`
+	Edges []edge `json:"edges"`
+}
+
+type node struct {
+	ID     string `json:"id"`
+	Known  bool   `json:"known"`
+	Sex    string `json:"sex,omitempty"`
+	Age    string `json:"age,omitempty"`
+	Family string `json:"family,omitempty"`
+}
+
+type edge struct {
+	Source      int      `json:"source"`
+	Target      int      `json:"target"`
+	Weight      float64  `json:"weight"`
+	Distance    int      `json:"distance,omitempty"`
+	Relatedness float64  `json:"relatedness,omitempty"`
+	Carried     []string `json:"carried,omitempty"`
+	Support     int      `json:"support,omitempty"`
+}
+
+// Write writes g as an HTML page drawing the pedigree with an inline
+// force-directed layout that can be panned, zoomed, and hovered for
+// the information on each individual and relationship
+// Relationships show their relational distance and the relatedness
+// expected at that distance, as links through unknowns have no
+// relatedness of their own
+func Write(w io.Writer, g *graph.Graph, precision util.Precision) error {
+	var names []string
+	nodes := g.Nodes()
+	for nodes.Next() {
+		if name, ok := g.IDToName(nodes.Node().ID()); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var p page
+	index := make(map[int64]int, len(names))
+	for i, name := range names {
+		info := g.Info(name)
+		index[info.ID] = i
+		n := node{ID: name, Known: g.IsKnown(name)}
+		if sex, ok := info.Field("sex"); ok {
+			n.Sex = sex
+		}
+		if age, ok := info.Field("age"); ok {
+			n.Age = age
+		}
+		if family, ok := info.Field("family"); ok {
+			n.Family = family
+		}
+		p.Nodes = append(p.Nodes, n)
+	}
+
+	edges := g.WeightedEdges()
+	for edges.Next() {
+		e := edges.WeightedEdge()
+		source, target := index[e.From().ID()], index[e.To().ID()]
+		if target < source {
+			source, target = target, source
+		}
+		ed := edge{Source: source, Target: target, Weight: util.RoundFloat(e.Weight(), precision)}
+		if dist, ok := g.EdgeDistance(names[source], names[target]); ok {
+			ed.Distance = int(dist)
+			ed.Relatedness = util.RoundFloat(math.Pow(0.5, float64(dist)), precision)
+		}
+		ed.Carried = g.Carried(names[source], names[target])
+		ed.Support = g.Support(names[source], names[target])
+		p.Edges = append(p.Edges, ed)
+	}
+	sort.Slice(p.Edges, func(i, j int) bool {
+		if p.Edges[i].Source != p.Edges[j].Source {
+			return p.Edges[i].Source < p.Edges[j].Source
+		}
+		return p.Edges[i].Target < p.Edges[j].Target
+	})
+
+	return pageTemplate.Execute(w, p)
+}
+
+var pageTemplate = template.Must(template.New("pedigree").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Pedigree</title>
+<style>
+html, body { margin: 0; height: 100%; overflow: hidden; font-family: sans-serif; }
+canvas { display: block; cursor: grab; }
+#tip { position: absolute; display: none; padding: 4px 8px; background: #fff; border: 1px solid #999; font-size: 12px; pointer-events: none; white-space: pre; }
+</style>
+</head>
+<body>
+<canvas id="pedigree"></canvas>
+<div id="tip"></div>
+<script>
+var data = {{.}};
+var canvas = document.getElementById("pedigree");
+var ctx = canvas.getContext("2d");
+var tip = document.getElementById("tip");
+var view = { x: 0, y: 0, scale: 1 };
+var nodes = data.nodes || [], edges = data.edges || [];
+
+// Lay out nodes by simple force simulation: linked nodes attract,
+// all nodes repel, settling over fewer steps for large pedigrees
+var steps = nodes.length > 1000 ? 50 : 300;
+nodes.forEach(function (n, i) {
+	var a = 2 * Math.PI * i / Math.max(nodes.length, 1);
+	n.x = 200 * Math.cos(a) + Math.random();
+	n.y = 200 * Math.sin(a) + Math.random();
+});
+for (var step = 0; step < steps; step++) {
+	var heat = 1 - step / steps;
+	nodes.forEach(function (n) { n.dx = 0; n.dy = 0; });
+	for (var i = 0; i < nodes.length; i++) {
+		for (var j = i + 1; j < nodes.length; j++) {
+			var a = nodes[i], b = nodes[j];
+			var dx = a.x - b.x, dy = a.y - b.y, d2 = dx * dx + dy * dy + 0.01;
+			var f = 900 / d2;
+			a.dx += dx * f; a.dy += dy * f; b.dx -= dx * f; b.dy -= dy * f;
+		}
+	}
+	edges.forEach(function (e) {
+		var a = nodes[e.source], b = nodes[e.target];
+		var dx = a.x - b.x, dy = a.y - b.y, d = Math.sqrt(dx * dx + dy * dy) + 0.01;
+		var f = (d - 40) / d * 0.1;
+		a.dx -= dx * f; a.dy -= dy * f; b.dx += dx * f; b.dy += dy * f;
+	});
+	nodes.forEach(function (n) {
+		var d = Math.sqrt(n.dx * n.dx + n.dy * n.dy) + 0.01, max = 20 * heat;
+		n.x += n.dx / d * Math.min(d, max);
+		n.y += n.dy / d * Math.min(d, max);
+	});
+}
+
+function toScreen(n) {
+	return { x: (n.x + view.x) * view.scale + canvas.width / 2, y: (n.y + view.y) * view.scale + canvas.height / 2 };
+}
+
+function draw() {
+	canvas.width = window.innerWidth;
+	canvas.height = window.innerHeight;
+	ctx.clearRect(0, 0, canvas.width, canvas.height);
+	edges.forEach(function (e) {
+		var a = toScreen(nodes[e.source]), b = toScreen(nodes[e.target]);
+		ctx.setLineDash(nodes[e.source].known && nodes[e.target].known ? [] : [4, 4]);
+		ctx.lineWidth = nodes[e.source].known && nodes[e.target].known ? 2 : 1;
+		ctx.strokeStyle = "#555";
+		ctx.beginPath(); ctx.moveTo(a.x, a.y); ctx.lineTo(b.x, b.y); ctx.stroke();
+	});
+	ctx.setLineDash([]);
+	nodes.forEach(function (n) {
+		var p = toScreen(n), r = n.known ? 8 : 5;
+		ctx.beginPath();
+		if (n.known && n.sex === "Male") {
+			ctx.rect(p.x - r, p.y - r, 2 * r, 2 * r);
+		} else if (n.known) {
+			ctx.arc(p.x, p.y, r, 0, 2 * Math.PI);
+		} else {
+			ctx.moveTo(p.x, p.y - r); ctx.lineTo(p.x + r, p.y); ctx.lineTo(p.x, p.y + r); ctx.lineTo(p.x - r, p.y); ctx.closePath();
+		}
+		ctx.fillStyle = n.known ? "yellow" : "#ddd";
+		ctx.fill(); ctx.strokeStyle = "#333"; ctx.stroke();
+		if (n.known) {
+			ctx.fillStyle = "#000"; ctx.font = "12px sans-serif";
+			ctx.fillText(n.id, p.x + r + 2, p.y + 4);
+		}
+	});
+}
+
+function nearest(x, y) {
+	var best = null, bestD = 100;
+	nodes.forEach(function (n) {
+		var p = toScreen(n), d = (p.x - x) * (p.x - x) + (p.y - y) * (p.y - y);
+		if (d < bestD) { best = { node: n }; bestD = d; }
+	});
+	if (best) { return best; }
+	edges.forEach(function (e) {
+		var a = toScreen(nodes[e.source]), b = toScreen(nodes[e.target]);
+		var dx = b.x - a.x, dy = b.y - a.y, len = dx * dx + dy * dy || 1;
+		var t = Math.max(0, Math.min(1, ((x - a.x) * dx + (y - a.y) * dy) / len));
+		var px = a.x + t * dx - x, py = a.y + t * dy - y, d = px * px + py * py;
+		if (d < 25 && (!best || d < bestD)) { best = { edge: e }; bestD = d; }
+	});
+	return best;
+}
+
+function describe(hit) {
+	if (hit.node) {
+		var n = hit.node, lines = [n.id + (n.known ? "" : " (unknown)")];
+		if (n.sex) { lines.push("Sex: " + n.sex); }
+		if (n.age) { lines.push("Age: " + n.age); }
+		if (n.family) { lines.push("Family: " + n.family); }
+		return lines.join("\n");
+	}
+	var e = hit.edge, lines = [nodes[e.source].id + " - " + nodes[e.target].id, "Weight: " + e.weight];
+	if (e.distance) {
+		lines.push("Distance: " + e.distance);
+		lines.push("Expected relatedness: " + e.relatedness);
+	}
+	if (e.support) { lines.push("Support: " + e.support + " pairs"); }
+	if (e.carried) { lines.push("From: " + e.carried.join("; ")); }
+	return lines.join("\n");
+}
+
+var drag = null;
+canvas.addEventListener("mousedown", function (ev) { drag = { x: ev.clientX, y: ev.clientY }; });
+window.addEventListener("mouseup", function () { drag = null; });
+canvas.addEventListener("mousemove", function (ev) {
+	if (drag) {
+		view.x += (ev.clientX - drag.x) / view.scale;
+		view.y += (ev.clientY - drag.y) / view.scale;
+		drag = { x: ev.clientX, y: ev.clientY };
+		draw();
+	}
+	var hit = nearest(ev.clientX, ev.clientY);
+	if (hit) {
+		tip.textContent = describe(hit);
+		tip.style.left = ev.clientX + 12 + "px";
+		tip.style.top = ev.clientY + 12 + "px";
+		tip.style.display = "block";
+	} else {
+		tip.style.display = "none";
+	}
+});
+canvas.addEventListener("wheel", function (ev) {
+	ev.preventDefault();
+	view.scale *= ev.deltaY < 0 ? 1.1 : 1 / 1.1;
+	draw();
+});
+window.addEventListener("resize", draw);
+draw();
+</script>
+</body>
+</html>
+`))
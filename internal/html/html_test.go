@@ -0,0 +1,31 @@
+package html_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/html"
+	"github.com/rhagenson/relped/internal/io/demographics"
+	"github.com/rhagenson/relped/internal/util"
+)
+
+func TestWrite(t *testing.T) {
+	g := graph.NewGraph([]string{"I1", "I2"})
+	g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 2))
+	g.AddSex("I1", demographics.Female)
+	out := new(strings.Builder)
+	if err := html.Write(out, g, util.Precision{}); err != nil {
+		t.Fatalf("Could not write HTML: %s", err)
+	}
+	for _, str := range []string{
+		`<!DOCTYPE html>`,
+		`{"id":"I1","known":true,"sex":"Female"}`,
+		`{"id":"U1","known":false}`,
+		`{"source":0,"target":2,"weight":2,"distance":2,"relatedness":0.25}`,
+	} {
+		if !strings.Contains(out.String(), str) {
+			t.Errorf("expected %s in:\n%s", str, out)
+		}
+	}
+}
@@ -1,13 +1,14 @@
 package demographics
 
 import (
-	"os"
+	"encoding/csv"
 	"strings"
 
 	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/gocarina/gocsv"
+	"github.com/rhagenson/relped/internal/exit"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -19,7 +20,8 @@ type ThreeColumnCsv struct {
 	indvs []string
 }
 
-func NewThreeColumnCsv(f *os.File) *ThreeColumnCsv {
+// NewThreeColumnCsv reads ID, Sex, and BirthYear columns from in
+func NewThreeColumnCsv(in *csv.Reader) *ThreeColumnCsv {
 	y := uint(time.Now().Year())
 	type entry struct {
 		ID        string `csv:"ID"`
@@ -29,8 +31,8 @@ func NewThreeColumnCsv(f *os.File) *ThreeColumnCsv {
 	entries := make([]*entry, 0, 100)
 
 	gocsv.FailIfUnmatchedStructTags = true
-	if err := gocsv.UnmarshalFile(f, &entries); err != nil {
-		log.Fatalf("Misread in CSV: %s, rename column to match names used here\n", err)
+	if err := gocsv.UnmarshalCSV(in, &entries); err != nil {
+		exit.Fatalf(exit.Parse, "Misread in CSV: %s, rename column to match names used here\n", err)
 	}
 
 	c := &ThreeColumnCsv{
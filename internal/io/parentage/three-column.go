@@ -1,10 +1,11 @@
 package parentage
 
 import (
-	"os"
+	"encoding/csv"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/gocarina/gocsv"
+	"github.com/rhagenson/relped/internal/exit"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -16,7 +17,8 @@ type ThreeColumnCsv struct {
 	indvs []string
 }
 
-func NewThreeColumnCsv(f *os.File) *ThreeColumnCsv {
+// NewThreeColumnCsv reads ID, Sire, and Dam columns from in
+func NewThreeColumnCsv(in *csv.Reader) *ThreeColumnCsv {
 	type entry struct {
 		ID   string `csv:"ID"`
 		Sire string `csv:"Sire"`
@@ -26,8 +28,8 @@ func NewThreeColumnCsv(f *os.File) *ThreeColumnCsv {
 	entries := make([]entry, 0, 100)
 
 	gocsv.FailIfUnmatchedStructTags = true
-	if err := gocsv.UnmarshalFile(f, &entries); err != nil {
-		log.Fatalf("Misread in CSV: %s, rename column to match names used here\n", err)
+	if err := gocsv.UnmarshalCSV(in, &entries); err != nil {
+		exit.Fatalf(exit.Parse, "Misread in CSV: %s, rename column to match names used here\n", err)
 	}
 
 	c := &ThreeColumnCsv{
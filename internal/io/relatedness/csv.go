@@ -1,13 +1,533 @@
 package relatedness
 
 import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
 	mapset "github.com/deckarep/golang-set"
+	"github.com/rhagenson/relped/internal/exit"
 	"github.com/rhagenson/relped/internal/unit"
 	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
+	log "github.com/sirupsen/logrus"
 )
 
+// Options alter how relatedness is read
+type Options struct {
+	Normalize       bool   // Rescale values outside of [0,1] to be [0,1]-bounded
+	NormalizeToData bool   // Rescale the observed range of values to [0,1]
+	Strict          bool   // Fail on questionable values rather than warn
+	Percent         bool   // Values are percentages (e.g. 50 for 0.5)
+	Intervals       bool   // Values may have confidence intervals (e.g. 0.45[0.30,0.60])
+	Categorical     bool   // Values are relationship categories (e.g. PO) rather than numbers
+	TrimIDs         bool   // Remove leading and trailing white space from IDs
+	CollectErrors   bool   // Report every failing line before stopping, rather than the first
+	MergeReciprocal bool   // Read (B,A) as (A,B), combining them by Aggregate
+	AssumeSymmetric bool   // Check values given for both (A,B) and (B,A) agree
+	MarkersColumn   string // Column with the number of markers behind each value
+	CarryColumn     string // Column whose value is kept with each pair for tracing
+	Header          HeaderMode
+	CSV             util.CsvOptions // How loosely CSV input is parsed
+	Rounding        util.Rounding   // How relatedness between two distances is rounded
+
+	// RelatednessColumns are read in place of Rel, combining the values
+	// of each row by Aggregate, or their mean when Aggregate is unset
+	RelatednessColumns []string
+
+	// Aggregate combines repeated values for a pair:
+	// "last" (the default), "first", "mean", "min", or "max"
+	Aggregate string
+
+	// MLRelateRelationships picks the "closest" or "furthest" of the plausible
+	// relationships ML-Relate lists, rather than the most likely relationship
+	MLRelateRelationships string
+	// MLRelateProbabilities scales relatedness by how probable ML-Relate
+	// finds the relationship, from the likelihoods of U, HS, FS, and PO
+	MLRelateProbabilities bool
+	// MLRelateColumns places the required ML-Relate columns by number,
+	// for exports whose columns are not named as ML-Relate writes them
+	MLRelateColumns ColumnNumbers
+}
+
+// ColumnNumbers are the numbers of columns, counted from 1,
+// with 0 leaving the column to be found by name
+type ColumnNumbers struct {
+	Ind1, Ind2, Category, Relatedness uint
+}
+
+// HeaderMode says whether input starts with a header row
+type HeaderMode int
+
+const (
+	DetectHeader  HeaderMode = iota // Header unless the first row holds a value
+	WithHeader                      // First row is always a header
+	WithoutHeader                   // First row is always data
+)
+
+// hasHeader reports whether record, the first row of input, is a header
+// as set by opts, detecting one when the value in col cannot be read
+// as a number, number with a confidence interval, or relationship
+func hasHeader(record []string, col int, opts Options) bool {
+	switch opts.Header {
+	case WithHeader:
+		return true
+	case WithoutHeader:
+		return false
+	}
+	if len(record) <= col {
+		return true
+	}
+	val := strings.TrimSpace(record[col])
+	if _, err := strconv.ParseFloat(val, 64); err == nil {
+		return false
+	}
+	if _, _, _, err := parseInterval(val); err == nil {
+		return false
+	}
+	return !isCategory(val)
+}
+
+// Aggregates are the accepted values of Options.Aggregate
+var Aggregates = []string{"last", "first", "mean", "min", "max"}
+
+// IsAggregate reports whether name is one of Aggregates
+func IsAggregate(name string) bool {
+	for _, agg := range Aggregates {
+		if name == agg {
+			return true
+		}
+	}
+	return false
+}
+
+// canonical orders a pair's IDs when reciprocal rows are merged
+func canonical(from, to string, opts Options) (string, string) {
+	if opts.MergeReciprocal && to < from {
+		return to, from
+	}
+	return from, to
+}
+
+// aggregateName is opts.Aggregate, or its default
+func aggregateName(opts Options) string {
+	if opts.Aggregate == "" {
+		return "last"
+	}
+	return opts.Aggregate
+}
+
+// aggregate combines prev, from earlier rows, with val from the
+// count-th row given for the same pair as set by opts
+func aggregate(prev, val float64, count int, opts Options) float64 {
+	switch opts.Aggregate {
+	case "first":
+		return prev
+	case "mean":
+		return prev + (val-prev)/float64(count)
+	case "min":
+		return math.Min(prev, val)
+	case "max":
+		return math.Max(prev, val)
+	default:
+		return val
+	}
+}
+
+// takesRow reports whether the row of val, rather than the earlier row
+// of kept, is the one a pair combined into agg by opts is taken from;
+// under "mean" that is the row closer to the mean so far, the earlier
+// on ties
+func takesRow(kept, val, agg float64, opts Options) bool {
+	switch opts.Aggregate {
+	case "first":
+		return false
+	case "mean", "min", "max":
+		return math.Abs(val-agg) < math.Abs(kept-agg)
+	default:
+		return true
+	}
+}
+
+// symmetricTolerance is how far apart values given for both (A,B)
+// and (B,A) may be while agreeing
+const symmetricTolerance = 1e-3
+
+// reciprocals remembers the value given for each ordered pair
+// to check that values given in both directions agree
+type reciprocals struct {
+	check bool
+	given map[[2]string]float64
+	lines map[[2]string]int
+}
+
+func newReciprocals(opts Options) *reciprocals {
+	return &reciprocals{
+		check: opts.AssumeSymmetric,
+		given: make(map[[2]string]float64),
+		lines: make(map[[2]string]int),
+	}
+}
+
+// add records val as given for from and to on line
+func (r *reciprocals) add(from, to string, val float64, line int) {
+	if r.check {
+		r.given[[2]string{from, to}] = val
+		r.lines[[2]string{from, to}] = line
+	}
+}
+
+// report warns of pairs whose values disagree, failing under opts.Strict
+func (r *reciprocals) report(opts Options, errs *rowErrors) {
+	var disagree [][2]string
+	for pair, val := range r.given {
+		if pair[0] < pair[1] {
+			if other, ok := r.given[[2]string{pair[1], pair[0]}]; ok && symmetricTolerance < math.Abs(val-other) {
+				disagree = append(disagree, pair)
+			}
+		}
+	}
+	sort.Slice(disagree, func(i, j int) bool {
+		return r.lines[disagree[i]] < r.lines[disagree[j]]
+	})
+	for _, pair := range disagree {
+		back := [2]string{pair[1], pair[0]}
+		if opts.Strict {
+			errs.reject(r.lines[back], "relatedness %v disagrees with %v given for %q and %q on line %d", r.given[back], r.given[pair], pair[0], pair[1], r.lines[pair])
+		} else {
+			log.Warnf("Relatedness of %q and %q disagrees: %v on line %d, %v on line %d\n", pair[0], pair[1], r.given[pair], r.lines[pair], r.given[back], r.lines[back])
+		}
+	}
+}
+
+// markerCounts remember the number of markers behind each pair's relatedness
+type markerCounts struct {
+	counts map[[2]string]uint
+	max    uint
+	errs   *rowErrors
+}
+
+func newMarkerCounts(opts Options) *markerCounts {
+	return &markerCounts{
+		counts: make(map[[2]string]uint),
+		errs:   newRowErrors(opts, opts.MarkersColumn),
+	}
+}
+
+// add records the count in val for from and to on line,
+// leaving the pair without a count when val is empty
+func (m *markerCounts) add(from, to, val string, line int) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return
+	}
+	n, err := strconv.ParseUint(val, 10, 64)
+	if err != nil || n == 0 {
+		m.errs.fail(line, "marker count %q is not a positive whole number", val)
+		return
+	}
+	m.counts[[2]string{from, to}] = uint(n)
+	if m.max < uint(n) {
+		m.max = uint(n)
+	}
+}
+
+// get is the count given for i1 and i2, in either order
+func (m *markerCounts) get(i1, i2 string) (uint, bool) {
+	if n, ok := m.counts[[2]string{i1, i2}]; ok {
+		return n, true
+	}
+	n, ok := m.counts[[2]string{i2, i1}]
+	return n, ok
+}
+
+// carried are values kept with each pair from the CarryColumn,
+// joined by "; " when a pair is given on several rows
+type carried map[[2]string]string
+
+// add keeps val for from and to, ignoring empty values
+func (c carried) add(from, to, val string) {
+	if val = strings.TrimSpace(val); val == "" {
+		return
+	}
+	if prev, ok := c[[2]string{from, to}]; ok {
+		val = prev + "; " + val
+	}
+	c[[2]string{from, to}] = val
+}
+
+// get is the value kept for i1 and i2, in either order
+func (c carried) get(i1, i2 string) (string, bool) {
+	if val, ok := c[[2]string{i1, i2}]; ok {
+		return val, true
+	}
+	val, ok := c[[2]string{i2, i1}]
+	return val, ok
+}
+
+// columnIndex is the index of the column named name in header, or -1
+func columnIndex(header []string, name string) int {
+	for i, col := range header {
+		if strings.TrimSpace(col) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// maxReportedErrors caps how many failing lines are reported
+// under Options.CollectErrors
+const maxReportedErrors = 50
+
+// rowErrors fails on the first failing line, or under opts.CollectErrors
+// reports each failing line and fails once all lines are read
+type rowErrors struct {
+	collect bool
+	column  string
+	n       int
+	code    int // Exit code, parsing failures outranking failed checks
+}
+
+func newRowErrors(opts Options, column string) *rowErrors {
+	return &rowErrors{collect: opts.CollectErrors, column: column}
+}
+
+// fail reports msg for the value in column on line, which could not be parsed
+func (e *rowErrors) fail(line int, format string, args ...interface{}) {
+	e.failWith(exit.Parse, line, format, args...)
+}
+
+// reject reports msg for the value in column on line, which failed a check
+func (e *rowErrors) reject(line int, format string, args ...interface{}) {
+	e.failWith(exit.Validation, line, format, args...)
+}
+
+func (e *rowErrors) failWith(code int, line int, format string, args ...interface{}) {
+	msg := fmt.Sprintf("Line %d, column %s: %s\n", line, e.column, fmt.Sprintf(format, args...))
+	if !e.collect {
+		exit.Fatalf(code, "%s", msg)
+	}
+	if e.code == 0 || code == exit.Parse {
+		e.code = code
+	}
+	e.n++
+	if e.n <= maxReportedErrors {
+		log.Error(msg)
+	}
+}
+
+// check stops if any line failed
+func (e *rowErrors) check() {
+	if e.n == 0 {
+		return
+	}
+	if maxReportedErrors < e.n {
+		log.Errorf("... and %d more\n", e.n-maxReportedErrors)
+	}
+	exit.Fatalf(e.code, "Cancelled further processing due to %d failing lines\n", e.n)
+}
+
+// isFinite reports whether val can be used as relatedness,
+// failing on line under opts.Strict when it cannot
+func isFinite(val float64, line int, opts Options, errs *rowErrors) bool {
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		if opts.Strict {
+			errs.reject(line, "relatedness is not a finite number: %v", val)
+		}
+		return false
+	}
+	return true
+}
+
+// fromPercent converts val from a percentage as set by opts,
+// warning when val on line is outside of a plausible percentage
+func fromPercent(val float64, line int, opts Options) float64 {
+	if !opts.Percent {
+		return val
+	}
+	if 100 < val {
+		log.Warnf("Relatedness on line %d is above 100 percent: %v\n", line, val)
+	}
+	return val / 100
+}
+
+// aboveOne reports whether val is above 1 and will be clamped to 1,
+// failing on line under opts.Strict
+// Values are left for normalization to rescale when it is set
+func aboveOne(val float64, line int, opts Options, errs *rowErrors) bool {
+	if val <= 1 || opts.Normalize || opts.NormalizeToData {
+		return false
+	}
+	if opts.Strict {
+		errs.reject(line, "relatedness is above 1: %v", val)
+	}
+	return true
+}
+
+// warnAboveOne reports how many values were clamped to 1
+func warnAboveOne(n int) {
+	if 0 < n {
+		log.Warnf("%d relatedness values were above 1, treating those as 1\n", n)
+	}
+}
+
+// idTrimmer removes white space around IDs, remembering
+// each form an ID was given in to report IDs that were merged
+type idTrimmer struct {
+	trim  bool
+	forms map[string]map[string]bool
+}
+
+func newIDTrimmer(opts Options) *idTrimmer {
+	return &idTrimmer{
+		trim:  opts.TrimIDs,
+		forms: make(map[string]map[string]bool),
+	}
+}
+
+// id returns raw trimmed as set by opts
+func (t *idTrimmer) id(raw string) string {
+	if !t.trim {
+		return raw
+	}
+	id := strings.TrimSpace(raw)
+	if _, ok := t.forms[id]; !ok {
+		t.forms[id] = make(map[string]bool)
+	}
+	t.forms[id][raw] = true
+	return id
+}
+
+// warnMerged reports IDs given in more than one form
+func (t *idTrimmer) warnMerged() {
+	ids := make([]string, 0, len(t.forms))
+	for id, forms := range t.forms {
+		if 1 < len(forms) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		log.Warnf("ID %q was given with differing white space, merged %d forms\n", id, len(t.forms[id]))
+	}
+}
+
+// warnNonFinite reports how many values were not finite
+func warnNonFinite(n int) {
+	if 0 < n {
+		log.Warnf("%d relatedness values were not finite numbers, treating those pairs as unrelated\n", n)
+	}
+}
+
+// warnTooDistant reports how many pairs of in have positive relatedness
+// too small to be a relationship, likely noise around zero, so should be
+// called once values are normalized
+func warnTooDistant(in CsvInput) {
+	n := 0
+	for _, pair := range in.Pairs() {
+		if 0 < in.Relatedness(pair[0], pair[1]) && in.RelDistance(pair[0], pair[1]) == relational.Unrelated {
+			n++
+		}
+	}
+	if 0 < n {
+		log.Warnf("%d relatedness values were above 0 but below ninth degree, treating those pairs as unrelated; lower --max-distance to drop weak pairs deliberately\n", n)
+	}
+}
+
+// normalize rescales rels as set by opts
+func normalize(rels map[string]map[string]unit.Relatedness, opts Options) map[string]map[string]unit.Relatedness {
+	switch {
+	case opts.NormalizeToData:
+		return util.NormalizeRelatednessToData(rels)
+	case opts.Normalize:
+		return util.NormalizeRelatedness(rels)
+	default:
+		return rels
+	}
+}
+
 type CsvInput interface {
 	Indvs() mapset.Set
+	Pairs() [][2]string
 	Relatedness(i1, i2 string) unit.Relatedness
 	RelDistance(i1, i2 string) relational.Degree
 }
+
+// IntervalInput is CsvInput which may also have confidence intervals
+type IntervalInput interface {
+	CsvInput
+	Interval(i1, i2 string) (low, high unit.Relatedness, ok bool)
+}
+
+// CategoryInput is CsvInput which may also have relationship categories
+type CategoryInput interface {
+	CsvInput
+	Category(i1, i2 string) (string, bool)
+}
+
+// MarkerInput is CsvInput which may also have the number of markers
+// behind each relatedness estimate
+type MarkerInput interface {
+	CsvInput
+	Markers(i1, i2 string) (uint, bool)
+	MaxMarkers() uint
+}
+
+// CarryInput is CsvInput which may also keep a value from each row,
+// such as a row number or note, to trace pairs back to their input
+type CarryInput interface {
+	CsvInput
+	Carried(i1, i2 string) (string, bool)
+}
+
+// interval matches a point estimate followed by its confidence interval,
+// either as 0.45[0.30,0.60] or 0.45 (0.30-0.60)
+var interval = regexp.MustCompile(`^\s*(-?[0-9]*\.?[0-9]+(?:[eE][-+]?[0-9]+)?)\s*[\[(]\s*(-?[0-9]*\.?[0-9]+(?:[eE][-+]?[0-9]+)?)\s*[,;-]\s*(-?[0-9]*\.?[0-9]+(?:[eE][-+]?[0-9]+)?)\s*[\])]\s*$`)
+
+// parseInterval reads the point estimate and confidence interval of val
+func parseInterval(val string) (point, low, high float64, err error) {
+	m := interval.FindStringSubmatch(val)
+	if m == nil {
+		return 0, 0, 0, fmt.Errorf("%q is not a value with a confidence interval", val)
+	}
+	nums := make([]float64, 3)
+	for i := range nums {
+		if nums[i], err = strconv.ParseFloat(m[i+1], 64); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	return nums[0], nums[1], nums[2], nil
+}
+
+// categories are the relationships accepted in place of relatedness
+var categories = []string{"PO", "FS", "HS", "U"}
+
+// isCategory reports whether cat is one of categories
+func isCategory(cat string) bool {
+	for _, c := range categories {
+		if cat == c {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryToRelatedness converts the category used by ML-Relate
+// to the relatedness used in its place
+func categoryToRelatedness(cat string) float64 {
+	switch cat {
+	case "PO":
+		return 0.5
+	case "FS":
+		return 0.25
+	case "HS":
+		return 0.125
+	case "U":
+		return 0.0
+	default:
+		return 0.0
+	}
+}
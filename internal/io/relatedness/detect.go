@@ -0,0 +1,68 @@
+package relatedness
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Relatedness input formats, as read by NewThreeColumnCsv and NewMLRelateCsv
+const (
+	ThreeColumnFormat = "three-column"
+	MLRelateFormat    = "ml-relate"
+)
+
+// Formats are the relatedness input formats that can be read
+var Formats = []string{ThreeColumnFormat, MLRelateFormat}
+
+// unreadFormats are the columns of outputs from other tools that are
+// recognized, but not read, so they can be named when seen
+var unreadFormats = []struct {
+	name    string
+	columns []string
+}{
+	{"KING .kin0", []string{"ID1", "ID2", "Kinship"}},
+	{"COANCESTRY", []string{"Ind1", "Ind2", "TrioEst"}},
+	{"COANCESTRY", []string{"Ind1", "Ind2", "WEst"}},
+}
+
+// DetectFormat is the format of f, one of Formats, found from its first
+// row, then rewinds f to be read again
+// ML-Relate and three-column input are recognized with or without a
+// header, as their readers do
+func DetectFormat(f *os.File, opts Options) (string, error) {
+	first := readFirst(f, opts.CSV)
+	if len(first) == 0 {
+		return "", fmt.Errorf("empty file")
+	}
+	cols := first
+	if len(cols) == 1 { // Other tools separate columns by white space
+		cols = strings.Fields(cols[0])
+	}
+	for _, format := range unreadFormats {
+		if hasColumns(cols, format.columns...) {
+			return "", fmt.Errorf("columns look like %s output, which cannot be read; convert it to ID1,ID2,Rel columns", format.name)
+		}
+	}
+	switch {
+	case hasColumns(first, "Ind1", "Ind2", "Relatedness"):
+		return MLRelateFormat, nil
+	case hasColumns(first, "ID1", "ID2"):
+		return ThreeColumnFormat, nil
+	case len(first) == len(mlRelateHeader) && !hasHeader(first, mlRelateLnLColumn, opts):
+		return MLRelateFormat, nil
+	case len(first) == 3 && !hasHeader(first, 2, opts):
+		return ThreeColumnFormat, nil
+	}
+	return "", fmt.Errorf("no format has columns: %s", strings.Join(cols, ", "))
+}
+
+// hasColumns reports whether header has every named column
+func hasColumns(header []string, names ...string) bool {
+	for _, name := range names {
+		if columnIndex(header, name) < 0 {
+			return false
+		}
+	}
+	return true
+}
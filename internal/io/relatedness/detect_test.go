@@ -0,0 +1,53 @@
+package relatedness_test
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rhagenson/relped/internal/io/relatedness"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tt := []struct {
+		name, in, exp string
+	}{
+		{"Three-column", "ID1,ID2,Rel\nI1,I2,0.5\n", relatedness.ThreeColumnFormat},
+		{"Three-column without header", "I1,I2,0.5\n", relatedness.ThreeColumnFormat},
+		{"Three-column with extra columns", "ID1,ID2,Rel,SNPs\nI1,I2,0.5,100\n", relatedness.ThreeColumnFormat},
+		{"ML-Relate", "Ind1,Ind2,R,LnL(R),U,HS,FS,PO,Relationships,Relatedness\nI1,I2,PO,-1.2,-,-3.4,-2.1,-1.2,\"PO,FS\",0.5\n", relatedness.MLRelateFormat},
+		{"ML-Relate without header", "I1,I2,PO,-1.2,-,-3.4,-2.1,-1.2,\"PO,FS\",0.5\n", relatedness.MLRelateFormat},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f := tempCsv(t, tc.in)
+			defer os.Remove(f.Name())
+			defer f.Close()
+			got, err := relatedness.DetectFormat(f, relatedness.Options{})
+			if err != nil {
+				t.Fatalf("Got error %s, Expected %s", err, tc.exp)
+			}
+			if got != tc.exp {
+				t.Errorf("Got %s, Expected %s", got, tc.exp)
+			}
+			if first, _ := f.Seek(0, io.SeekCurrent); first != 0 {
+				t.Errorf("Expected the file to be rewound")
+			}
+		})
+	}
+	t.Run("Unread and unknown formats are errors", func(t *testing.T) {
+		for in, exp := range map[string]string{
+			"FID1\tID1\tFID2\tID2\tN_SNP\tHetHet\tIBS0\tKinship\n": "KING",
+			"A,B,C,D\n1,2,3,4\n": "A, B, C, D",
+			"":                   "empty",
+		} {
+			f := tempCsv(t, in)
+			defer os.Remove(f.Name())
+			defer f.Close()
+			if _, err := relatedness.DetectFormat(f, relatedness.Options{}); err == nil || !strings.Contains(err.Error(), exp) {
+				t.Errorf("Got error %v for %q, Expected one mentioning %s", err, in, exp)
+			}
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package relatedness
+
+import (
+	mapset "github.com/deckarep/golang-set"
+	"github.com/rhagenson/relped/internal/unit"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+// Duplicate is a pair related closely enough to likely be the same
+// individual sampled twice, or identical twins
+type Duplicate struct {
+	ID1, ID2    string
+	Relatedness unit.Relatedness
+}
+
+// Duplicates finds every pair in in with relatedness of at least threshold
+func Duplicates(in CsvInput, threshold float64) []Duplicate {
+	var dups []Duplicate
+	for _, pair := range in.Pairs() {
+		if pair[0] == pair[1] {
+			continue
+		}
+		if rel := in.Relatedness(pair[0], pair[1]); threshold <= float64(rel) {
+			dups = append(dups, Duplicate{ID1: pair[0], ID2: pair[1], Relatedness: rel})
+		}
+	}
+	return dups
+}
+
+var _ CsvInput = new(MergedCsv)
+
+// MergedCsv is relatedness input with duplicates merged into one
+// individual, named by the first of their IDs in sorted order
+type MergedCsv struct {
+	rels  map[string]map[string]unit.Relatedness
+	dists map[string]map[string]relational.Degree
+	as    map[string]string // Merged IDs to the ID they were merged into
+	indvs mapset.Set
+}
+
+// MergeDuplicates merges each pair in dups, along with any other
+// individuals they are duplicates of. Each remaining pair takes the
+// closest relatedness of the pairs merged into it.
+func MergeDuplicates(in CsvInput, dups []Duplicate) *MergedCsv {
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		if p, ok := parent[id]; ok && p != id {
+			root := find(p)
+			parent[id] = root
+			return root
+		}
+		return id
+	}
+	for _, dup := range dups {
+		r1, r2 := find(dup.ID1), find(dup.ID2)
+		if r2 < r1 {
+			r1, r2 = r2, r1
+		}
+		if r1 != r2 {
+			parent[r2] = r1
+		}
+	}
+
+	c := &MergedCsv{
+		rels:  make(map[string]map[string]unit.Relatedness),
+		dists: make(map[string]map[string]relational.Degree),
+		as:    make(map[string]string),
+		indvs: mapset.NewSet(),
+	}
+	for _, indv := range in.Indvs().ToSlice() {
+		id := indv.(string)
+		root := find(id)
+		if root != id {
+			c.as[id] = root
+		}
+		c.indvs.Add(root)
+	}
+	for _, pair := range in.Pairs() {
+		from, to := find(pair[0]), find(pair[1])
+		if from == to && pair[0] != pair[1] {
+			continue // Duplicates are not related to themselves
+		}
+		rel := in.Relatedness(pair[0], pair[1])
+		if prev, ok := c.lookup(from, to); ok && rel <= prev {
+			continue
+		}
+		if _, ok := c.rels[from]; !ok {
+			c.rels[from] = make(map[string]unit.Relatedness)
+			c.dists[from] = make(map[string]relational.Degree)
+		}
+		delete(c.rels[to], from)
+		delete(c.dists[to], from)
+		c.rels[from][to] = rel
+		c.dists[from][to] = in.RelDistance(pair[0], pair[1])
+	}
+	return c
+}
+
+// lookup is the relatedness kept for i1 and i2, in either order
+func (c *MergedCsv) lookup(i1, i2 string) (unit.Relatedness, bool) {
+	if rel, ok := c.rels[i1][i2]; ok {
+		return rel, true
+	}
+	rel, ok := c.rels[i2][i1]
+	return rel, ok
+}
+
+// MergedInto is the ID id was merged into
+// Returns false if id was not merged
+func (c *MergedCsv) MergedInto(id string) (string, bool) {
+	into, ok := c.as[id]
+	return into, ok
+}
+
+func (c *MergedCsv) Indvs() mapset.Set {
+	return c.indvs.Clone()
+}
+
+func (c *MergedCsv) Pairs() [][2]string {
+	return pairs(c.rels)
+}
+
+func (c *MergedCsv) Relatedness(from, to string) unit.Relatedness {
+	rel, _ := c.lookup(from, to)
+	return rel
+}
+
+func (c *MergedCsv) RelDistance(from, to string) relational.Degree {
+	if dist, ok := c.dists[from][to]; ok {
+		return dist
+	}
+	if dist, ok := c.dists[to][from]; ok {
+		return dist
+	}
+	return relational.Unrelated
+}
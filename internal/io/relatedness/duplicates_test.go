@@ -0,0 +1,40 @@
+package relatedness_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+func TestDuplicates(t *testing.T) {
+	f := tempCsv(t, "ID1,ID2,Rel\nI1,I1b,0.98\nI1,I2,0.25\nI1b,I2,0.5\nI2,I3,0.125\n")
+	defer os.Remove(f.Name())
+	defer f.Close()
+	c := relatedness.NewThreeColumnCsv(f, relatedness.Options{})
+
+	dups := relatedness.Duplicates(c, 0.9)
+	if len(dups) != 1 || dups[0].ID1 != "I1" || dups[0].ID2 != "I1b" {
+		t.Fatalf("Got %+v, Expected I1 and I1b", dups)
+	}
+
+	t.Run("Duplicates are merged", func(t *testing.T) {
+		m := relatedness.MergeDuplicates(c, dups)
+		if n := m.Indvs().Cardinality(); n != 3 {
+			t.Errorf("Got %d individuals, Expected 3", n)
+		}
+		if into, ok := m.MergedInto("I1b"); !ok || into != "I1" {
+			t.Errorf("Got %q (%t), Expected I1b merged into I1", into, ok)
+		}
+		if got := m.Relatedness("I2", "I1"); got != 0.5 {
+			t.Errorf("Got %v, Expected the closer 0.5", got)
+		}
+		if got := m.RelDistance("I1", "I2"); got != relational.First {
+			t.Errorf("Got %d, Expected %d", got, relational.First)
+		}
+		if got := m.Relatedness("I2", "I3"); got != 0.125 {
+			t.Errorf("Got %v, Expected 0.125", got)
+		}
+	})
+}
@@ -0,0 +1,390 @@
+package relatedness
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/unit"
+	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+var _ CategoryInput = new(MLRelateCsv)
+var _ MarkerInput = new(MLRelateCsv)
+var _ CarryInput = new(MLRelateCsv)
+
+// mlRelateColumns locates the columns of ML-Relate output by name,
+// with -1 for columns not present
+type mlRelateColumns struct {
+	ind1, ind2, r, relatedness int // Required
+	lnl, u, hs, fs, po         int // Needed for probabilities
+	relationships              int // Needed to choose among relationships
+}
+
+// mlRelateHeader are the columns ML-Relate writes, in order
+var mlRelateHeader = []string{"Ind1", "Ind2", "R", "LnL(R)", "U", "HS", "FS", "PO", "Relationships", "Relatedness"}
+
+// mlRelateLnLColumn is the index of LnL(R) in mlRelateHeader,
+// a number in every record by which a header is told apart
+const mlRelateLnLColumn = 3
+
+// newMLRelateColumns finds each column named in header, or numbered by
+// nums, erroring if a required column is missing or beyond width
+func newMLRelateColumns(header []string, nums ColumnNumbers, width int) (mlRelateColumns, error) {
+	index := func(name string) int { return columnIndex(header, name) }
+	cols := mlRelateColumns{
+		ind1:          index("Ind1"),
+		ind2:          index("Ind2"),
+		r:             index("R"),
+		relatedness:   index("Relatedness"),
+		lnl:           index("LnL(R)"),
+		u:             index("U"),
+		hs:            index("HS"),
+		fs:            index("FS"),
+		po:            index("PO"),
+		relationships: index("Relationships"),
+	}
+	for _, num := range []struct {
+		name string
+		num  uint
+		col  *int
+	}{
+		{"Ind1", nums.Ind1, &cols.ind1},
+		{"Ind2", nums.Ind2, &cols.ind2},
+		{"R", nums.Category, &cols.r},
+		{"Relatedness", nums.Relatedness, &cols.relatedness},
+	} {
+		switch {
+		case num.num == 0:
+		case uint(width) < num.num:
+			return cols, fmt.Errorf("column %d given for %s, but rows have %d columns", num.num, num.name, width)
+		default:
+			*num.col = int(num.num) - 1
+		}
+	}
+	var missing []string
+	for name, i := range map[string]int{"Ind1": cols.ind1, "Ind2": cols.ind2, "R": cols.r, "Relatedness": cols.relatedness} {
+		if i < 0 {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return cols, fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+	return cols, nil
+}
+
+// hasLikelihoods reports whether every column needed for probabilities is present
+func (cols mlRelateColumns) hasLikelihoods() bool {
+	return 0 <= cols.lnl && 0 <= cols.u && 0 <= cols.hs && 0 <= cols.fs && 0 <= cols.po
+}
+
+// MLRelateCsv is the relatedness output of ML-Relate, using the
+// most likely relationship (R) for distance and the estimated
+// Relatedness for weight
+type MLRelateCsv struct {
+	rels    map[string]map[string]unit.Relatedness
+	dists   map[string]map[string]relational.Degree
+	cats    map[[2]string]string
+	markers *markerCounts
+	carried carried
+	indvs   mapset.Set
+}
+
+func NewMLRelateCsv(f *os.File, opts Options) *MLRelateCsv {
+	r := util.NewCsvReader(f, opts.CSV)
+	records, err := r.ReadAll()
+	if err != nil {
+		exit.Fatalf(exit.Parse, "Misread in ML-Relate CSV: %s\n", err)
+	}
+	if len(records) == 0 {
+		exit.Fatalf(exit.Parse, "Misread in ML-Relate CSV: empty input file\n")
+	}
+	// Headerless input is read as if it had the columns ML-Relate writes
+	header := mlRelateHeader
+	width := len(records[0])
+	offset := 1 // Line of the first record
+	if hasHeader(records[0], mlRelateLnLColumn, opts) {
+		header = records[0]
+		records = records[1:]
+		offset = 2
+	} else if len(records[0]) < len(mlRelateHeader) {
+		exit.Fatalf(exit.Parse, "Misread in ML-Relate CSV: no header and %d columns rather than %d\n", len(records[0]), len(mlRelateHeader))
+	} else {
+		log.Infof("No header in ML-Relate input, reading columns as %s\n", strings.Join(mlRelateHeader, ", "))
+	}
+	if len(records) == 0 {
+		exit.Fatalf(exit.Parse, "Misread in ML-Relate CSV: no rows after the header\n")
+	}
+	cols, err := newMLRelateColumns(header, opts.MLRelateColumns, width)
+	if err != nil {
+		exit.Fatalf(exit.Parse, "Misread in ML-Relate CSV header: %s\n", err)
+	}
+	if opts.MLRelateRelationships != "" && cols.relationships < 0 {
+		exit.Fatalf(exit.Parse, "Misread in ML-Relate CSV header: missing Relationships column\n")
+	}
+	if opts.MLRelateProbabilities && !cols.hasLikelihoods() {
+		exit.Fatalf(exit.Parse, "Misread in ML-Relate CSV header: missing LnL(R), U, HS, FS, or PO column\n")
+	}
+	markersCol, carryCol := -1, -1
+	if opts.MarkersColumn != "" {
+		if markersCol = columnIndex(header, opts.MarkersColumn); markersCol < 0 {
+			exit.Fatalf(exit.Parse, "Misread in ML-Relate CSV header: missing %s column\n", opts.MarkersColumn)
+		}
+	}
+	if opts.CarryColumn != "" {
+		if carryCol = columnIndex(header, opts.CarryColumn); carryCol < 0 {
+			exit.Fatalf(exit.Parse, "Misread in ML-Relate CSV header: missing %s column\n", opts.CarryColumn)
+		}
+	}
+
+	c := &MLRelateCsv{
+		rels:    make(map[string]map[string]unit.Relatedness, len(records)),
+		dists:   make(map[string]map[string]relational.Degree, len(records)),
+		cats:    make(map[[2]string]string, len(records)),
+		markers: newMarkerCounts(opts),
+		carried: make(carried),
+		indvs:   mapset.NewSet(),
+	}
+
+	ids := newIDTrimmer(opts)
+	errs := newRowErrors(opts, "Relatedness")
+	counts := make(map[[2]string]int, len(records))
+	raw := make(map[[2]string]float64, len(records))  // Combined values before clamping
+	kept := make(map[[2]string]float64, len(records)) // Values of the rows categories are taken from
+	given := make(map[[2]string]bool, len(records))
+	recips := newReciprocals(opts)
+	nonFinite, clamped := 0, 0
+	for i, record := range records {
+		id1, id2 := ids.id(record[cols.ind1]), ids.id(record[cols.ind2])
+		from, to := canonical(id1, id2, opts)
+		cat := record[cols.r]
+		counts[[2]string{from, to}]++
+
+		if given[[2]string{id1, id2}] { // Rows merged from (B,A) are not duplicates
+			log.Warnf("Relatedness pair ID %q and ID %q duplicated, combining by %s with: %v\n", id1, id2, aggregateName(opts), record)
+		}
+		given[[2]string{id1, id2}] = true
+		if _, ok := c.rels[from]; !ok {
+			c.rels[from] = make(map[string]unit.Relatedness)
+			c.dists[from] = make(map[string]relational.Degree)
+		}
+
+		if opts.MLRelateRelationships != "" {
+			cat = pickRelationship(cat, record[cols.relationships], opts.MLRelateRelationships)
+		}
+		dist := util.CategoryToDist(cat)
+		val, err := strconv.ParseFloat(record[cols.relatedness], 64)
+		if err == nil {
+			val = fromPercent(val, i+offset, opts)
+		}
+		switch {
+		case err != nil:
+			log.Warnf("Could not read Relatedness on line %d, using relatedness of %s: %s\n", i+offset, cat, err)
+			val = categoryToRelatedness(cat)
+		case !isFinite(val, i+offset, opts, errs):
+			nonFinite++
+			dist = relational.Unrelated
+			val = 0.0
+		case val <= 0 && dist != relational.Unrelated:
+			// Related pairs need positive relatedness to have a finite weight
+			val = categoryToRelatedness(cat)
+		}
+		if opts.MLRelateProbabilities && dist != relational.Unrelated {
+			if probs, err := categoryProbabilities(record, cols); err == nil {
+				val = categoryToRelatedness(cat) * probs[cat]
+			} else {
+				log.Warnf("Could not read likelihoods on line %d, using Relatedness: %s\n", i+offset, err)
+			}
+		}
+		recips.add(id1, id2, val, i+offset)
+		// Values are combined as given, then clamped, as for three-column
+		// input, keeping the relationship of the row the value is taken from
+		key := [2]string{from, to}
+		combined := val
+		if prev, ok := raw[key]; ok {
+			combined = aggregate(prev, val, counts[key], opts)
+		}
+		raw[key] = combined
+		if prev, ok := kept[key]; !ok || takesRow(prev, val, combined, opts) {
+			kept[key] = val
+			c.dists[from][to] = dist
+			c.cats[key] = cat
+		}
+		switch {
+		case combined < 0: // Negative value just means unrelated
+			combined = 0.0
+		case aboveOne(combined, i+offset, opts, errs):
+			clamped++
+			combined = 1.0
+		}
+		c.rels[from][to] = unit.Relatedness(combined)
+		if 0 <= markersCol {
+			c.markers.add(from, to, record[markersCol], i+offset)
+		}
+		if 0 <= carryCol {
+			c.carried.add(from, to, record[carryCol])
+		}
+
+		c.indvs.Add(from)
+		c.indvs.Add(to)
+	}
+
+	recips.report(opts, errs)
+	errs.check()
+	c.markers.errs.check()
+	ids.warnMerged()
+	warnNonFinite(nonFinite)
+	warnAboveOne(clamped)
+	c.rels = normalize(c.rels, opts)
+	warnTooDistant(c)
+
+	return c
+}
+
+func (c *MLRelateCsv) Indvs() mapset.Set {
+	return c.indvs.Clone()
+}
+
+func (c *MLRelateCsv) Pairs() [][2]string {
+	return pairs(c.rels)
+}
+
+func (c *MLRelateCsv) Relatedness(from, to string) unit.Relatedness {
+	if val, ok := c.rels[from][to]; ok {
+		return val
+	}
+	if val, ok := c.rels[to][from]; ok {
+		return val
+	}
+	return unit.Relatedness(0)
+}
+
+// Category is the relationship used for i1 and i2
+// Returns false if the pair was not recorded
+func (c *MLRelateCsv) Category(i1, i2 string) (string, bool) {
+	if cat, ok := c.cats[[2]string{i1, i2}]; ok {
+		return cat, true
+	}
+	cat, ok := c.cats[[2]string{i2, i1}]
+	return cat, ok
+}
+
+// Markers is the number of markers behind the relatedness of i1 and i2
+// Returns false if no count was given
+func (c *MLRelateCsv) Markers(i1, i2 string) (uint, bool) {
+	return c.markers.get(i1, i2)
+}
+
+// MaxMarkers is the most markers behind any relatedness, zero if none were given
+func (c *MLRelateCsv) MaxMarkers() uint {
+	return c.markers.max
+}
+
+// Carried is the value of Options.CarryColumn given for i1 and i2
+// Returns false if none was given
+func (c *MLRelateCsv) Carried(i1, i2 string) (string, bool) {
+	return c.carried.get(i1, i2)
+}
+
+func (c *MLRelateCsv) RelDistance(from, to string) relational.Degree {
+	if dist, ok := c.dists[from][to]; ok {
+		return dist
+	}
+	if dist, ok := c.dists[to][from]; ok {
+		return dist
+	}
+	return relational.Unrelated
+}
+
+// parseRelationships splits the Relationships column of ML-Relate
+// ML-Relate lists every relationship whose likelihood is not significantly
+// lower than the most likely, separated by commas and/or spaces (e.g. "HS, FS")
+func parseRelationships(field string) []string {
+	return strings.FieldsFunc(field, func(r rune) bool {
+		return r == ',' || r == ';' || r == '/' || unicode.IsSpace(r)
+	})
+}
+
+// pickRelationship chooses among the plausible relationships by choice,
+// either the "closest" or the "furthest" relational distance, falling back
+// to the most likely relationship when none are listed
+func pickRelationship(likely, field, choice string) string {
+	picked := likely
+	for _, cat := range parseRelationships(field) {
+		dist := util.CategoryToDist(cat)
+		if dist == relational.Unrelated && cat != "U" {
+			log.Warnf("Unrecognized relationship %q in Relationships: %q\n", cat, field)
+			continue
+		}
+		cur := util.CategoryToDist(picked)
+		switch {
+		case picked == "":
+			picked = cat
+		case choice == "closest" && (cur == relational.Unrelated || (dist != relational.Unrelated && dist < cur)):
+			picked = cat
+		case choice == "furthest" && (dist == relational.Unrelated || (cur != relational.Unrelated && cur < dist)):
+			picked = cat
+		}
+	}
+	return picked
+}
+
+// categoryProbabilities converts the log-likelihood of each relationship,
+// where "-" marks the most likely with LnL(R), into probabilities
+// assuming all relationships are equally likely beforehand
+func categoryProbabilities(record []string, cols mlRelateColumns) (map[string]float64, error) {
+	catCols := map[string]int{"U": cols.u, "HS": cols.hs, "FS": cols.fs, "PO": cols.po}
+	lnls := make(map[string]float64, len(catCols))
+	best := math.Inf(-1)
+	for cat, col := range catCols {
+		field := record[col]
+		if field == "-" {
+			field = record[cols.lnl]
+		}
+		lnl, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		lnls[cat] = lnl
+		best = math.Max(best, lnl)
+	}
+
+	// Shift by the best log-likelihood to avoid underflow
+	total := 0.0
+	probs := make(map[string]float64, len(lnls))
+	for cat, lnl := range lnls {
+		probs[cat] = math.Exp(lnl - best)
+		total += probs[cat]
+	}
+	for cat := range probs {
+		probs[cat] /= total
+	}
+	return probs, nil
+}
+
+// pairs lists every recorded pair in sorted order
+func pairs(rels map[string]map[string]unit.Relatedness) [][2]string {
+	ps := make([][2]string, 0, len(rels))
+	for from, m := range rels {
+		for to := range m {
+			ps = append(ps, [2]string{from, to})
+		}
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i][0] != ps[j][0] {
+			return ps[i][0] < ps[j][0]
+		}
+		return ps[i][1] < ps[j][1]
+	})
+	return ps
+}
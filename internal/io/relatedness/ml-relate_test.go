@@ -0,0 +1,247 @@
+package relatedness_test
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
+)
+
+func TestMLRelateCsv(t *testing.T) {
+	const contents = "Ind1,Ind2,R,LnL(R),U,HS,FS,PO,Relationships,Relatedness\n" +
+		"I1,I2,HS,-30.1,-35.2,-,-30.5,-33.0,\"HS, PO\",0.20\n" +
+		"I1,I3,FS,-30.1,-35.2,-31.0,-,-33.0,FS,0.25\n"
+	tests := []struct {
+		choice string
+		want   relational.Degree
+	}{
+		{"", relational.Second},
+		{"closest", relational.First},
+		{"furthest", relational.Second},
+	}
+	for _, tc := range tests {
+		t.Run("Relationships "+tc.choice, func(t *testing.T) {
+			f := tempCsv(t, contents)
+			defer os.Remove(f.Name())
+			defer f.Close()
+			c := relatedness.NewMLRelateCsv(f, relatedness.Options{MLRelateRelationships: tc.choice})
+			if got := c.RelDistance("I1", "I2"); got != tc.want {
+				t.Errorf("Got %v, Expected %v", got, tc.want)
+			}
+			if got := c.RelDistance("I1", "I3"); got != relational.Second {
+				t.Errorf("Got %v, Expected single listed relationship kept", got)
+			}
+		})
+	}
+	t.Run("Empty and header only input are parse errors", func(t *testing.T) {
+		for _, contents := range []string{"", "Ind1,Ind2,R,LnL(R),U,HS,FS,PO,Relationships,Relatedness\n"} {
+			f := tempCsv(t, contents)
+			defer os.Remove(f.Name())
+			defer f.Close()
+			code := exitCode(t, func() { relatedness.NewMLRelateCsv(f, relatedness.Options{}) })
+			if code != exit.Parse {
+				t.Errorf("Got exit code %d for %q, Expected %d", code, contents, exit.Parse)
+			}
+		}
+	})
+	t.Run("Probabilities scale relatedness", func(t *testing.T) {
+		// PO and FS equally likely, HS and U negligible
+		f := tempCsv(t, "Ind1,Ind2,R,LnL(R),U,HS,FS,PO,Relationships,Relatedness\n"+
+			"I1,I2,PO,-30.0,-900.0,-900.0,-30.0,-,\"PO, FS\",0.45\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewMLRelateCsv(f, relatedness.Options{MLRelateProbabilities: true})
+		if got := c.Relatedness("I1", "I2"); math.Abs(float64(got)-0.25) > 1e-9 {
+			t.Errorf("Got %v, Expected 0.25", got)
+		}
+	})
+	t.Run("Reciprocal rows are combined before clamping", func(t *testing.T) {
+		f := tempCsv(t, "Ind1,Ind2,R,Relatedness\nI1,I2,PO,1.4\nI2,I1,PO,0.2\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewMLRelateCsv(f, relatedness.Options{MergeReciprocal: true, Aggregate: "mean"})
+		if got := c.Relatedness("I1", "I2"); math.Abs(float64(got)-0.8) > 1e-9 {
+			t.Errorf("Got %v, Expected the mean of 1.4 and 0.2", got)
+		}
+	})
+	t.Run("Relationships are taken from the row kept", func(t *testing.T) {
+		f := tempCsv(t, "Ind1,Ind2,R,Relatedness\n"+
+			"I1,I2,HS,0.125\nI1,I2,PO,0.5\n"+
+			"I1,I3,PO,0.5\nI1,I3,HS,0.125\n"+
+			"I1,I4,HS,0.125\nI1,I4,PO,0.5\nI1,I4,PO,0.5\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		tt := []struct {
+			aggregate string
+			exp       [3]string
+		}{
+			{"", [3]string{"PO", "HS", "PO"}},
+			{"first", [3]string{"HS", "PO", "HS"}},
+			{"min", [3]string{"HS", "HS", "HS"}},
+			{"max", [3]string{"PO", "PO", "PO"}},
+			{"mean", [3]string{"HS", "PO", "PO"}},
+		}
+		for _, tc := range tt {
+			f.Seek(0, 0)
+			c := relatedness.NewMLRelateCsv(f, relatedness.Options{Aggregate: tc.aggregate})
+			for i, to := range []string{"I2", "I3", "I4"} {
+				got, _ := c.Category("I1", to)
+				if got != tc.exp[i] {
+					t.Errorf("Got %s for I1 and %s by %q, Expected %s", got, to, tc.aggregate, tc.exp[i])
+				}
+				if dist := c.RelDistance("I1", to); dist != util.CategoryToDist(tc.exp[i]) {
+					t.Errorf("Got distance %v for I1 and %s by %q, Expected that of %s", dist, to, tc.aggregate, tc.exp[i])
+				}
+			}
+		}
+	})
+	t.Run("Columns are found by name", func(t *testing.T) {
+		f := tempCsv(t, "Index,Ind2,Ind1,Relatedness,R\n1,I2,I1,0.5,PO\n2,I3,I1,0.25,HS\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewMLRelateCsv(f, relatedness.Options{})
+		if got := c.RelDistance("I1", "I2"); got != relational.First {
+			t.Errorf("Got %v, Expected %v", got, relational.First)
+		}
+		if got := c.Relatedness("I1", "I3"); got != 0.25 {
+			t.Errorf("Got %v, Expected 0.25", got)
+		}
+	})
+	t.Run("Headerless input is read in ML-Relate order", func(t *testing.T) {
+		f := tempCsv(t, "I1,I2,HS,-30.1,-35.2,-,-30.5,-33.0,HS,0.20\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewMLRelateCsv(f, relatedness.Options{})
+		if got := c.Relatedness("I1", "I2"); math.Abs(float64(got)-0.20) > 1e-9 {
+			t.Errorf("Got %v, Expected 0.20 from the first row", got)
+		}
+	})
+	const header = "Ind1,Ind2,R,LnL(R),U,HS,FS,PO,Relationships,Relatedness\n"
+	fixtures := []struct {
+		name     string
+		contents string
+		opts     relatedness.Options
+		exit     int // Expected exit code, zero if read
+		dist     relational.Degree
+		cat      string
+		rel      float64
+	}{
+		{
+			name:     "Missing column is a parse error",
+			contents: "Ind1,Ind2,R,LnL(R)\nI1,I2,PO,-30.0\n",
+			exit:     exit.Parse,
+		},
+		{
+			name:     "Probabilities without likelihoods are a parse error",
+			contents: "Ind1,Ind2,R,Relatedness\nI1,I2,PO,0.5\n",
+			opts:     relatedness.Options{MLRelateProbabilities: true},
+			exit:     exit.Parse,
+		},
+		{
+			name:     "Unknown relationship code is unrelated",
+			contents: header + "I1,I2,XX,-30.0,-31.0,-,-32.0,-33.0,XX,0.2\n",
+			dist:     relational.Unrelated,
+			cat:      "XX",
+			rel:      0.2,
+		},
+		{
+			name:     "Unknown plausible relationship is skipped",
+			contents: header + "I1,I2,HS,-30.0,-31.0,-,-32.0,-33.0,\"HS, XX\",0.2\n",
+			opts:     relatedness.Options{MLRelateRelationships: "furthest"},
+			dist:     relational.Second,
+			cat:      "HS",
+			rel:      0.2,
+		},
+		{
+			name:     "Tied relationships keep the most likely",
+			contents: header + "I1,I2,FS,-30.0,-31.0,-30.5,-,-33.0,\"HS, FS\",0.3\n",
+			opts:     relatedness.Options{MLRelateRelationships: "closest"},
+			dist:     relational.Second,
+			cat:      "FS",
+			rel:      0.3,
+		},
+		{
+			name:     "Unreadable likelihoods keep Relatedness",
+			contents: header + "I1,I2,PO,-30.0,x,-900.0,-30.0,-,PO,0.45\n",
+			opts:     relatedness.Options{MLRelateProbabilities: true},
+			dist:     relational.First,
+			cat:      "PO",
+			rel:      0.45,
+		},
+		{
+			name:     "Unrelated pairs are not scaled by probability",
+			contents: header + "I1,I2,U,-30.0,-,-31.0,-32.0,-33.0,U,0.05\n",
+			opts:     relatedness.Options{MLRelateProbabilities: true},
+			dist:     relational.Unrelated,
+			cat:      "U",
+			rel:      0.05,
+		},
+	}
+	for _, tc := range fixtures {
+		t.Run(tc.name, func(t *testing.T) {
+			f := tempCsv(t, tc.contents)
+			defer os.Remove(f.Name())
+			defer f.Close()
+			var c *relatedness.MLRelateCsv
+			code := exitCode(t, func() { c = relatedness.NewMLRelateCsv(f, tc.opts) })
+			switch {
+			case tc.exit != 0 && code != tc.exit:
+				t.Fatalf("Got exit code %d, Expected %d", code, tc.exit)
+			case tc.exit != 0:
+				return
+			case code != -1:
+				t.Fatalf("Got exit code %d, Expected the input to be read", code)
+			}
+			if got := c.RelDistance("I1", "I2"); got != tc.dist {
+				t.Errorf("Got %v, Expected %v", got, tc.dist)
+			}
+			if got, _ := c.Category("I1", "I2"); got != tc.cat {
+				t.Errorf("Got %q, Expected %q", got, tc.cat)
+			}
+			if got := c.Relatedness("I1", "I2"); math.Abs(float64(got)-tc.rel) > 1e-9 {
+				t.Errorf("Got %v, Expected %v", got, tc.rel)
+			}
+		})
+	}
+	t.Run("Converted output is read back as three-column input", func(t *testing.T) {
+		f := tempCsv(t, contents)
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewMLRelateCsv(f, relatedness.Options{})
+		buf := new(bytes.Buffer)
+		if err := relatedness.WriteThreeColumnCsv(buf, c); err != nil {
+			t.Fatalf("Could not convert: %s", err)
+		}
+		out := tempCsv(t, buf.String())
+		defer os.Remove(out.Name())
+		defer out.Close()
+		converted := relatedness.NewThreeColumnCsv(out, relatedness.Options{})
+		for _, pair := range c.Pairs() {
+			if got, want := converted.Relatedness(pair[0], pair[1]), c.Relatedness(pair[0], pair[1]); got != want {
+				t.Errorf("Got %v for %v, Expected %v", got, pair, want)
+			}
+		}
+		if n := len(converted.Pairs()); n != len(c.Pairs()) {
+			t.Errorf("Got %d pairs, Expected %d", n, len(c.Pairs()))
+		}
+	})
+	t.Run("Columns are placed by number", func(t *testing.T) {
+		f := tempCsv(t, "Row,First,Second,Rel,Est\n1,I1,I2,PO,0.5\n2,I1,I3,HS,0.25\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewMLRelateCsv(f, relatedness.Options{
+			MLRelateColumns: relatedness.ColumnNumbers{Ind1: 2, Ind2: 3, Category: 4, Relatedness: 5},
+		})
+		if got := c.RelDistance("I1", "I2"); got != relational.First {
+			t.Errorf("Got %v, Expected %v", got, relational.First)
+		}
+		if got := c.Relatedness("I1", "I3"); got != 0.25 {
+			t.Errorf("Got %v, Expected 0.25", got)
+		}
+	})
+}
@@ -1,27 +1,39 @@
 package relatedness
 
 import (
+	"encoding/csv"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/gocarina/gocsv"
+	"github.com/rhagenson/relped/internal/exit"
 	"github.com/rhagenson/relped/internal/unit"
 	"github.com/rhagenson/relped/internal/unit/relational"
 	"github.com/rhagenson/relped/internal/util"
 	log "github.com/sirupsen/logrus"
 )
 
-var _ CsvInput = new(ThreeColumnCsv)
+var _ IntervalInput = new(ThreeColumnCsv)
+var _ CategoryInput = new(ThreeColumnCsv)
+var _ MarkerInput = new(ThreeColumnCsv)
+var _ CarryInput = new(ThreeColumnCsv)
 
 type ThreeColumnCsv struct {
-	rels     map[string]map[string]unit.Relatedness
-	dists    map[string]map[string]relational.Degree
-	indvs    mapset.Set
-	min, max float64
+	rels      map[string]map[string]unit.Relatedness
+	dists     map[string]map[string]relational.Degree
+	intervals map[[2]string][2]unit.Relatedness
+	cats      map[[2]string]string // Pairs read as relationships
+	markers   *markerCounts
+	carried   carried
+	indvs     mapset.Set
+	min, max  float64
+	rounding  util.Rounding
 }
 
-func NewThreeColumnCsv(f *os.File, normalize bool) *ThreeColumnCsv {
+func NewThreeColumnCsv(f *os.File, opts Options) *ThreeColumnCsv {
 	type entry struct {
 		ID1 string `csv:"ID1"`
 		ID2 string `csv:"ID2"`
@@ -29,27 +41,103 @@ func NewThreeColumnCsv(f *os.File, normalize bool) *ThreeColumnCsv {
 	}
 	entries := make([]*entry, 0, 100)
 
-	gocsv.FailIfUnmatchedStructTags = true
-	if err := gocsv.UnmarshalFile(f, &entries); err != nil {
-		log.Fatalf("Misread in CSV: %s, rename column to match names used here\n", err)
+	// Markers and carried values are read by name, so are read apart
+	// from the fixed columns
+	var markers, carry []string
+	if opts.MarkersColumn != "" {
+		markers = readColumn(f, opts.MarkersColumn, opts.CSV)
+	}
+	if opts.CarryColumn != "" {
+		carry = readColumn(f, opts.CarryColumn, opts.CSV)
+	}
+	var relCols [][]string
+	for _, name := range opts.RelatednessColumns {
+		relCols = append(relCols, readColumn(f, name, opts.CSV))
+	}
+
+	// Headerless input is read as if it had the expected header
+	var in io.Reader = f
+	offset := 2 // Line of the first entry
+	first := readFirst(f, opts.CSV)
+	if len(first) == 0 {
+		exit.Fatalf(exit.Parse, "Misread in CSV: empty input file\n")
+	}
+	if hasHeader(first, 2, opts) {
+		checkHeader(first, len(relCols) == 0)
+	} else {
+		if opts.MarkersColumn != "" || opts.CarryColumn != "" || len(opts.RelatednessColumns) != 0 {
+			exit.Fatalf(exit.Usage, "Misread in CSV: marker, carried, and relatedness columns are found by name, which needs a header\n")
+		}
+		names := []string{"ID1", "ID2", "Rel"}
+		for i := len(names); i < len(first); i++ {
+			names = append(names, "_"+strconv.Itoa(i+1))
+		}
+		in = io.MultiReader(strings.NewReader(strings.Join(names, ",")+"\n"), f)
+		offset = 1
+		log.Infof("No header in relatedness input, reading columns as ID1, ID2, Rel\n")
+	}
+
+	r := util.NewCsvReader(in, opts.CSV)
+	if relCols != nil { // Rel is not needed in place of columns
+		type idEntry struct {
+			ID1 string `csv:"ID1"`
+			ID2 string `csv:"ID2"`
+		}
+		idEntries := make([]*idEntry, 0, 100)
+		if err := gocsv.UnmarshalCSV(r, &idEntries); err != nil {
+			exit.Fatalf(exit.Parse, "Misread in CSV: %s, rename column to match names used here\n", err)
+		}
+		for _, e := range idEntries {
+			entries = append(entries, &entry{ID1: e.ID1, ID2: e.ID2})
+		}
+	} else if err := gocsv.UnmarshalCSV(r, &entries); err != nil {
+		exit.Fatalf(exit.Parse, "Misread in CSV: %s, rename column to match names used here\n", err)
+	}
+	if len(entries) == 0 {
+		exit.Fatalf(exit.Parse, "Misread in CSV: no rows after the header\n")
 	}
 
 	c := &ThreeColumnCsv{
-		rels:  make(map[string]map[string]unit.Relatedness, len(entries)),
-		dists: make(map[string]map[string]relational.Degree, len(entries)),
-		indvs: mapset.NewSet(),
+		rels:      make(map[string]map[string]unit.Relatedness, len(entries)),
+		dists:     make(map[string]map[string]relational.Degree, len(entries)),
+		intervals: make(map[[2]string][2]unit.Relatedness),
+		cats:      make(map[[2]string]string),
+		markers:   newMarkerCounts(opts),
+		carried:   make(carried),
+		indvs:     mapset.NewSet(),
+		rounding:  opts.Rounding,
 	}
 
 	pairs := make(map[string][]string, len(entries))
-	for _, e := range entries {
-		from := e.ID1
-		to := e.ID2
+	ids := newIDTrimmer(opts)
+	errs := newRowErrors(opts, "Rel")
+	colErrs := newRowErrors(opts, strings.Join(opts.RelatednessColumns, ", "))
+	errs.collect = errs.collect || opts.Categorical // Unknown relationships are always reported together
+	counts := make(map[[2]string]int, len(entries))
+	raw := make(map[[2]string]float64, len(entries)) // Combined values before clamping
+	recips := newReciprocals(opts)
+	nonFinite, clamped := 0, 0
+	for i, e := range entries {
+		id1, id2 := ids.id(e.ID1), ids.id(e.ID2)
+		from, to := canonical(id1, id2, opts)
 		rel := e.Rel
+		if relCols != nil {
+			rel = combineColumns(relCols, i, i+offset, opts, colErrs)
+		}
+		counts[[2]string{from, to}]++
+		if markers != nil {
+			c.markers.add(from, to, markers[i], i+offset)
+		}
+		if carry != nil {
+			c.carried.add(from, to, carry[i])
+		}
 
-		if vs, ok := pairs[from]; ok {
+		// Pairs are kept as given, so rows merged from (B,A) are not
+		// taken as duplicates of (A,B)
+		if vs, ok := pairs[id1]; ok {
 			for _, v := range vs {
-				if v == to {
-					log.Warnf("Relatedness pair ID %q and ID %q duplicated, using: %+v\n", from, to, e)
+				if v == id2 {
+					log.Warnf("Relatedness pair ID %q and ID %q duplicated, combining by %s with: %+v\n", id1, id2, aggregateName(opts), e)
 				}
 			}
 		}
@@ -62,46 +150,183 @@ func NewThreeColumnCsv(f *os.File, normalize bool) *ThreeColumnCsv {
 		}
 
 		// Set relatedness and distance values
-		if val, err := strconv.ParseFloat(rel, 64); err == nil {
-			c.dists[from][to] = util.RelToLevel(val)
+		if opts.Categorical {
+			if !isCategory(rel) {
+				errs.fail(i+offset, "unknown relationship %q, expected one of: %s", rel, strings.Join(categories, ", "))
+			}
+			c.dists[from][to] = util.CategoryToDist(rel)
+			c.cats[[2]string{from, to}] = rel
+			c.addRelatedness(from, to, categoryToRelatedness(rel))
+			recips.add(id1, id2, categoryToRelatedness(rel), i+offset)
+			c.indvs.Add(from)
+			c.indvs.Add(to)
+			pairs[id1] = append(pairs[id1], id2)
+			continue
+		}
+		val, err := strconv.ParseFloat(rel, 64)
+		if err != nil && opts.Intervals {
+			if point, low, high, ierr := parseInterval(rel); ierr == nil {
+				val, err = point, nil
+				c.intervals[[2]string{from, to}] = [2]unit.Relatedness{
+					unit.Relatedness(fromPercent(low, i+offset, opts)),
+					unit.Relatedness(fromPercent(high, i+offset, opts)),
+				}
+			}
+		}
+		if err == nil {
+			if !isFinite(val, i+offset, opts, errs) {
+				nonFinite++
+				val = 0.0
+			}
+			val = fromPercent(val, i+offset, opts)
+			recips.add(id1, id2, val, i+offset)
+			// Values are combined as given, then clamped, so a value
+			// stored as clamped does not skew those after it
+			key := [2]string{from, to}
+			if prev, ok := raw[key]; ok {
+				val = aggregate(prev, val, counts[key], opts)
+			}
+			raw[key] = val
+			if aboveOne(val, i+offset, opts, errs) {
+				clamped++
+				val = 1.0
+			}
+			c.dists[from][to] = util.RelToLevel(val, opts.Rounding)
 			if 0 < val {
 				c.addRelatedness(from, to, val)
 			} else { // Negative value just means unrelated
 				c.addRelatedness(from, to, 0.0)
 			}
 		} else {
-			c.dists[from][to] = util.CategoryToDist(rel)
-			switch rel {
-			case "PO":
-				c.addRelatedness(from, to, 0.5)
-			case "FS":
-				c.addRelatedness(from, to, 0.25)
-			case "HS":
-				c.addRelatedness(from, to, 0.125)
-			case "U":
-				c.addRelatedness(from, to, 0.0)
-			default:
-				c.addRelatedness(from, to, 0.0)
+			if opts.Strict && !isCategory(rel) {
+				errs.reject(i+offset, "relatedness %q is neither a number nor a relationship", rel)
 			}
+			c.dists[from][to] = util.CategoryToDist(rel)
+			c.cats[[2]string{from, to}] = rel
+			c.addRelatedness(from, to, categoryToRelatedness(rel))
+			recips.add(id1, id2, categoryToRelatedness(rel), i+offset)
 		}
 
 		c.indvs.Add(from)
 		c.indvs.Add(to)
-		if _, ok := pairs[from]; ok {
-			pairs[from] = append(pairs[from], to)
+		if _, ok := pairs[id1]; ok {
+			pairs[id1] = append(pairs[id1], id2)
 		} else {
-			pairs[from] = make([]string, 0, len(entries))
-			pairs[from] = append(pairs[from], to)
+			pairs[id1] = make([]string, 0, len(entries))
+			pairs[id1] = append(pairs[id1], id2)
 		}
 	}
 
-	if normalize {
-		c.rels = util.NormalizeRelatedness(c.rels)
-	}
+	recips.report(opts, errs)
+	colErrs.check()
+	errs.check()
+	c.markers.errs.check()
+	ids.warnMerged()
+	warnNonFinite(nonFinite)
+	warnAboveOne(clamped)
+	c.rels = normalize(c.rels, opts)
+	warnTooDistant(c)
 
 	return c
 }
 
+// WriteThreeColumnCsv writes the relatedness of every pair of in as
+// ID1,ID2,Rel rows, as read by NewThreeColumnCsv
+func WriteThreeColumnCsv(out io.Writer, in CsvInput) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"ID1", "ID2", "Rel"})
+	for _, pair := range in.Pairs() {
+		rel := in.Relatedness(pair[0], pair[1])
+		w.Write([]string{pair[0], pair[1], strconv.FormatFloat(float64(rel), 'f', -1, 64)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// checkHeader stops unless header names ID1, ID2, and, if needRel, Rel
+// Columns are checked here rather than by gocsv, whose check is shared
+// by every reader
+func checkHeader(header []string, needRel bool) {
+	required := []string{"ID1", "ID2", "Rel"}
+	if !needRel { // Rel is not needed in place of columns
+		required = required[:2]
+	}
+	var missing []string
+	for _, name := range required {
+		found := false
+		for _, col := range header {
+			found = found || col == name
+		}
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) != 0 {
+		exit.Fatalf(exit.Parse, "Misread in CSV header: missing %s column, rename column to match names used here\n", strings.Join(missing, ", "))
+	}
+}
+
+// combineColumns combines the values of row in cols, read from line,
+// by opts.Aggregate or else their mean
+func combineColumns(cols [][]string, row, line int, opts Options, errs *rowErrors) string {
+	if opts.Aggregate == "" {
+		opts.Aggregate = "mean"
+	}
+	var combined float64
+	count := 0
+	for _, col := range cols {
+		val, err := strconv.ParseFloat(strings.TrimSpace(col[row]), 64)
+		if err != nil {
+			errs.fail(line, "relatedness %q is not a number", col[row])
+			continue
+		}
+		if count++; count == 1 {
+			combined = val
+		} else {
+			combined = aggregate(combined, val, count, opts)
+		}
+	}
+	return strconv.FormatFloat(combined, 'g', -1, 64)
+}
+
+// readFirst reads the first row of f as set by opts, then rewinds f
+// to be read again
+// Returns nil if f is empty or cannot be read
+func readFirst(f *os.File, opts util.CsvOptions) []string {
+	record, err := util.NewCsvReader(f, opts).Read()
+	if err != nil {
+		record = nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		exit.Fatalf(exit.IO, "Could not reread CSV: %s\n", err)
+	}
+	return record
+}
+
+// readColumn reads the values of the column named name, one per row,
+// as set by opts, then rewinds f to be read again
+func readColumn(f *os.File, name string, opts util.CsvOptions) []string {
+	records, err := util.NewCsvReader(f, opts).ReadAll()
+	if err != nil {
+		exit.Fatalf(exit.Parse, "Misread in CSV: %s\n", err)
+	}
+	if len(records) == 0 {
+		exit.Fatalf(exit.Parse, "Misread in CSV: empty input file\n")
+	}
+	col := columnIndex(records[0], name)
+	if col < 0 {
+		exit.Fatalf(exit.Parse, "Misread in CSV header: missing %s column\n", name)
+	}
+	vals := make([]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		vals = append(vals, record[col])
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		exit.Fatalf(exit.IO, "Could not reread CSV: %s\n", err)
+	}
+	return vals
+}
+
 func (c *ThreeColumnCsv) addRelatedness(from, to string, rel float64) {
 	c.rels[from][to] = unit.Relatedness(rel)
 }
@@ -110,6 +335,10 @@ func (c *ThreeColumnCsv) Indvs() mapset.Set {
 	return c.indvs.Clone()
 }
 
+func (c *ThreeColumnCsv) Pairs() [][2]string {
+	return pairs(c.rels)
+}
+
 func (c *ThreeColumnCsv) Relatedness(from, to string) unit.Relatedness {
 	if innerRels, ok := c.rels[from]; ok {
 		if val, ok := innerRels[to]; ok {
@@ -124,6 +353,49 @@ func (c *ThreeColumnCsv) Relatedness(from, to string) unit.Relatedness {
 	return unit.Relatedness(0)
 }
 
+// Interval is the confidence interval given with the relatedness of i1 and i2
+// Returns false if no interval was given
+func (c *ThreeColumnCsv) Interval(i1, i2 string) (low, high unit.Relatedness, ok bool) {
+	if ci, ok := c.intervals[[2]string{i1, i2}]; ok {
+		return ci[0], ci[1], true
+	}
+	if ci, ok := c.intervals[[2]string{i2, i1}]; ok {
+		return ci[0], ci[1], true
+	}
+	return 0, 0, false
+}
+
+// Category is the relationship given for i1 and i2
+// Returns false if relatedness was given as a number
+func (c *ThreeColumnCsv) Category(i1, i2 string) (string, bool) {
+	if cat, ok := c.cats[[2]string{i1, i2}]; ok {
+		return cat, true
+	}
+	cat, ok := c.cats[[2]string{i2, i1}]
+	return cat, ok
+}
+
+// Markers is the number of markers behind the relatedness of i1 and i2
+// Returns false if no count was given
+func (c *ThreeColumnCsv) Markers(i1, i2 string) (uint, bool) {
+	return c.markers.get(i1, i2)
+}
+
+// MaxMarkers is the most markers behind any relatedness, zero if none were given
+func (c *ThreeColumnCsv) MaxMarkers() uint {
+	return c.markers.max
+}
+
+// Carried is the value of Options.CarryColumn given for i1 and i2
+// Returns false if none was given
+func (c *ThreeColumnCsv) Carried(i1, i2 string) (string, bool) {
+	return c.carried.get(i1, i2)
+}
+
 func (c *ThreeColumnCsv) RelDistance(from, to string) relational.Degree {
-	return util.RelToLevel(float64(c.Relatedness(from, to)))
+	// Relationships set distance directly, e.g. HS is second degree at 0.125
+	if cat, ok := c.Category(from, to); ok {
+		return util.CategoryToDist(cat)
+	}
+	return util.RelToLevel(float64(c.Relatedness(from, to)), c.rounding)
 }
@@ -0,0 +1,400 @@
+package relatedness_test
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gocarina/gocsv"
+	"github.com/rhagenson/relped/internal/exit"
+	"github.com/rhagenson/relped/internal/io/relatedness"
+	"github.com/rhagenson/relped/internal/unit"
+	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// tempCsv writes contents to a temporary file, opened for reading
+// Callers should remove the file when done
+func tempCsv(t *testing.T, contents string) *os.File {
+	t.Helper()
+	f, err := ioutil.TempFile("", "relped-*.csv")
+	if err != nil {
+		t.Fatalf("Could not create temporary file: %s", err)
+	}
+	if _, err := f.WriteString(contents); err != nil {
+		t.Fatalf("Could not write temporary file: %s", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatalf("Could not rewind temporary file: %s", err)
+	}
+	return f
+}
+
+// exitCode runs read, returning the code it exits with, or -1 if it returns
+func exitCode(t *testing.T, read func()) (code int) {
+	t.Helper()
+	type exited struct{ code int }
+	logger := log.StandardLogger()
+	defer func(exit func(int)) { logger.ExitFunc = exit }(logger.ExitFunc)
+	logger.ExitFunc = func(code int) { panic(exited{code}) }
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(exited)
+			if !ok {
+				panic(r)
+			}
+			code = e.code
+		}
+	}()
+	read()
+	return -1
+}
+
+func TestThreeColumnCsv(t *testing.T) {
+	t.Run("Empty and header only input are parse errors", func(t *testing.T) {
+		for _, contents := range []string{"", "ID1,ID2,Rel\n"} {
+			f := tempCsv(t, contents)
+			defer os.Remove(f.Name())
+			defer f.Close()
+			code := exitCode(t, func() { relatedness.NewThreeColumnCsv(f, relatedness.Options{}) })
+			if code != exit.Parse {
+				t.Errorf("Got exit code %d for %q, Expected %d", code, contents, exit.Parse)
+			}
+		}
+	})
+	t.Run("Collected errors report every failing line", func(t *testing.T) {
+		hook := test.NewGlobal()
+		defer hook.Reset()
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,high\nI1,I3,0.5\nI2,I3,low\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		code := exitCode(t, func() { relatedness.NewThreeColumnCsv(f, relatedness.Options{Strict: true, CollectErrors: true}) })
+		if code != exit.Validation {
+			t.Errorf("Got exit code %d, Expected %d", code, exit.Validation)
+		}
+		var lines []string
+		for _, e := range hook.AllEntries() {
+			if e.Level == log.ErrorLevel {
+				lines = append(lines, e.Message)
+			}
+		}
+		if len(lines) != 2 || !strings.HasPrefix(lines[0], "Line 2,") || !strings.HasPrefix(lines[1], "Line 4,") {
+			t.Errorf("Got %q, Expected errors for lines 2 and 4", lines)
+		}
+
+		hook.Reset()
+		f.Seek(0, 0)
+		code = exitCode(t, func() { relatedness.NewThreeColumnCsv(f, relatedness.Options{Strict: true}) })
+		if code != exit.Validation {
+			t.Errorf("Got exit code %d, Expected %d", code, exit.Validation)
+		}
+		if n := len(hook.AllEntries()); n != 1 || !strings.HasPrefix(hook.LastEntry().Message, "Line 2,") {
+			t.Errorf("Got %d entries, Expected to stop at line 2", n)
+		}
+	})
+	t.Run("Failures exit with the code of their kind", func(t *testing.T) {
+		cols := []string{"Wang", "Ritland"}
+		tt := []struct {
+			name     string
+			contents string
+			opts     relatedness.Options
+			code     int
+		}{
+			{"unreadable value", "ID1,ID2,Wang,Ritland\nI1,I2,x,0.5\n", relatedness.Options{RelatednessColumns: cols}, exit.Parse},
+			{"strict check", "ID1,ID2,Rel\nI1,I2,1.5\n", relatedness.Options{Strict: true}, exit.Validation},
+			{"missing column", "ID1,ID2,Wang\nI1,I2,0.5\n", relatedness.Options{RelatednessColumns: cols}, exit.Parse},
+			{"unreadable value outranks strict check", "ID1,ID2,Wang,Ritland\nI1,I2,1.5,1.5\nI1,I3,x,0.2\n",
+				relatedness.Options{RelatednessColumns: cols, Strict: true, CollectErrors: true}, exit.Parse},
+		}
+		for _, tc := range tt {
+			f := tempCsv(t, tc.contents)
+			defer os.Remove(f.Name())
+			defer f.Close()
+			if code := exitCode(t, func() { relatedness.NewThreeColumnCsv(f, tc.opts) }); code != tc.code {
+				t.Errorf("Got exit code %d for %s, Expected %d", code, tc.name, tc.code)
+			}
+		}
+	})
+	t.Run("Non-finite values are unrelated", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,NaN\nI1,I3,Inf\nI2,I3,0.5\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{Normalize: true})
+		for _, pair := range [][2]string{{"I1", "I2"}, {"I1", "I3"}} {
+			if got := c.RelDistance(pair[0], pair[1]); got != relational.Unrelated {
+				t.Errorf("Got %v for %v, Expected %v", got, pair, relational.Unrelated)
+			}
+		}
+		if got := c.Relatedness("I2", "I3"); got != 0.5 {
+			t.Errorf("Got %v, Expected normalization unaffected by non-finite values", got)
+		}
+	})
+	t.Run("Percentages are read as fractions", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,50\nI1,I3,12.5\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{Percent: true})
+		if got := c.RelDistance("I1", "I2"); got != relational.First {
+			t.Errorf("Got %v, Expected %v", got, relational.First)
+		}
+		if got := c.Relatedness("I1", "I3"); got != 0.125 {
+			t.Errorf("Got %v, Expected 0.125", got)
+		}
+	})
+	t.Run("Values above one are clamped to one", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,1.5\nI1,I3,0.25\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{})
+		if got := c.Relatedness("I1", "I2"); got != 1 {
+			t.Errorf("Got %v, Expected 1", got)
+		}
+		if got := c.RelDistance("I1", "I2"); got != relational.First {
+			t.Errorf("Got %v, Expected %v", got, relational.First)
+		}
+	})
+	t.Run("Confidence intervals are read", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,\"0.5[0.30,0.60]\"\nI1,I3,0.25 (0.1-0.4)\nI2,I3,0.125\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{Intervals: true})
+		if got := c.Relatedness("I1", "I2"); got != 0.5 {
+			t.Errorf("Got %v, Expected 0.5", got)
+		}
+		if low, high, ok := c.Interval("I3", "I1"); !ok || low != 0.1 || high != 0.4 {
+			t.Errorf("Got [%v,%v] (%t), Expected [0.1,0.4]", low, high, ok)
+		}
+		if _, _, ok := c.Interval("I2", "I3"); ok {
+			t.Errorf("Expected no interval without one given")
+		}
+	})
+	t.Run("Relationships are read as categories", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,PO\nI1,I3,HS\nI2,I3,U\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{Categorical: true})
+		tt := []struct {
+			pair [2]string
+			dist relational.Degree
+		}{
+			{[2]string{"I1", "I2"}, relational.First},
+			{[2]string{"I1", "I3"}, relational.Second},
+			{[2]string{"I2", "I3"}, relational.Unrelated},
+		}
+		for _, tc := range tt {
+			if got := c.RelDistance(tc.pair[0], tc.pair[1]); got != tc.dist {
+				t.Errorf("Got %v for %v, Expected %v", got, tc.pair, tc.dist)
+			}
+		}
+	})
+	t.Run("Negatives stay unrelated under normalization", func(t *testing.T) {
+		for _, opts := range []relatedness.Options{{Normalize: true}, {NormalizeToData: true}} {
+			f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,-0.5\nI1,I3,0.25\nI2,I3,0.5\n")
+			defer os.Remove(f.Name())
+			defer f.Close()
+			c := relatedness.NewThreeColumnCsv(f, opts)
+			if got := c.RelDistance("I1", "I2"); got != relational.Unrelated {
+				t.Errorf("Got %v with %+v, Expected %v", got, opts, relational.Unrelated)
+			}
+		}
+	})
+	t.Run("White space around IDs is trimmed", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Rel\nI1 ,I2,0.5\n I1,I3,0.25\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{TrimIDs: true})
+		if n := c.Indvs().Cardinality(); n != 3 {
+			t.Errorf("Got %d individuals, Expected 3", n)
+		}
+		if got := c.Relatedness("I1", "I3"); got != 0.25 {
+			t.Errorf("Got %v, Expected 0.25", got)
+		}
+	})
+	t.Run("Reciprocal rows are merged", func(t *testing.T) {
+		tt := []struct {
+			agg string
+			exp unit.Relatedness
+		}{
+			{"", 0.25},
+			{"first", 0.5},
+			{"mean", 0.375},
+			{"min", 0.25},
+			{"max", 0.5},
+		}
+		for _, tc := range tt {
+			f := tempCsv(t, "ID1,ID2,Rel\nI2,I1,0.5\nI1,I2,0.25\n")
+			defer os.Remove(f.Name())
+			defer f.Close()
+			c := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true, Aggregate: tc.agg})
+			if n := len(c.Pairs()); n != 1 {
+				t.Errorf("Got %d pairs with %q, Expected 1", n, tc.agg)
+			}
+			if got := c.Relatedness("I2", "I1"); got != tc.exp {
+				t.Errorf("Got %v with %q, Expected %v", got, tc.agg, tc.exp)
+			}
+		}
+	})
+	t.Run("Reciprocal rows are combined before clamping", func(t *testing.T) {
+		hook := test.NewGlobal()
+		defer hook.Reset()
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,-0.2\nI2,I1,0.4\nI1,I3,0.5\nI1,I3,0.5\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{MergeReciprocal: true, Aggregate: "mean"})
+		if got := c.Relatedness("I1", "I2"); math.Abs(float64(got)-0.1) > 1e-9 {
+			t.Errorf("Got %v, Expected the mean of -0.2 and 0.4", got)
+		}
+		duplicated := 0
+		for _, e := range hook.AllEntries() {
+			if strings.Contains(e.Message, "duplicated") {
+				duplicated++
+			}
+		}
+		if duplicated != 1 {
+			t.Errorf("Got %d duplicate warnings, Expected only I1 and I3", duplicated)
+		}
+	})
+	t.Run("Triangular input is read in both directions", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,0.5\nI1,I3,0.25\nI2,I3,0.125\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{AssumeSymmetric: true})
+		for _, pair := range [][2]string{{"I1", "I2"}, {"I1", "I3"}, {"I2", "I3"}} {
+			if c.Relatedness(pair[0], pair[1]) != c.Relatedness(pair[1], pair[0]) {
+				t.Errorf("Expected %v to be symmetric", pair)
+			}
+		}
+	})
+	t.Run("Marker counts are read by column", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Rel,SNPs\nI1,I2,0.5,400\nI1,I3,0.25,100\nI2,I3,0.125,\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{MarkersColumn: "SNPs"})
+		if n, ok := c.Markers("I3", "I1"); !ok || n != 100 {
+			t.Errorf("Got %d (%t), Expected 100", n, ok)
+		}
+		if _, ok := c.Markers("I2", "I3"); ok {
+			t.Errorf("Expected no count for an empty value")
+		}
+		if got := c.MaxMarkers(); got != 400 {
+			t.Errorf("Got %d, Expected 400", got)
+		}
+		if got := c.Relatedness("I1", "I2"); got != 0.5 {
+			t.Errorf("Got %v, Expected 0.5", got)
+		}
+	})
+	t.Run("Headers are detected", func(t *testing.T) {
+		tt := []struct {
+			name     string
+			contents string
+			header   relatedness.HeaderMode
+			pairs    int
+		}{
+			{"header", "ID1,ID2,Rel\nI1,I2,0.5\n", relatedness.DetectHeader, 1},
+			{"no header", "I1,I2,0.5\nI1,I3,0.25\n", relatedness.DetectHeader, 2},
+			{"no header with intervals", "I1,I2,\"0.45[0.30,0.60]\"\n", relatedness.DetectHeader, 1},
+			{"no header with relationships", "I1,I2,PO\nI1,I3,HS\n", relatedness.DetectHeader, 2},
+			{"forced data", "I1,I2,0.5\nI1,I3,0.25\n", relatedness.WithoutHeader, 2},
+		}
+		for _, tc := range tt {
+			f := tempCsv(t, tc.contents)
+			defer os.Remove(f.Name())
+			defer f.Close()
+			c := relatedness.NewThreeColumnCsv(f, relatedness.Options{Header: tc.header, Intervals: true})
+			if n := len(c.Pairs()); n != tc.pairs {
+				t.Errorf("Got %d pairs with %s, Expected %d", n, tc.name, tc.pairs)
+			}
+			if got := c.Relatedness("I1", "I2"); got == 0 {
+				t.Errorf("Expected the first pair to be kept with %s", tc.name)
+			}
+		}
+	})
+	t.Run("Several relatedness columns are combined", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Wang,Ritland,LynchLi\nI1,I2,0.4,0.5,0.6\nI1,I3,0.25,0.2,0.3\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		for _, tc := range []struct {
+			aggregate string
+			exp       unit.Relatedness
+		}{
+			{"", 0.5},
+			{"mean", 0.5},
+			{"max", 0.6},
+			{"first", 0.4},
+		} {
+			f.Seek(0, 0)
+			c := relatedness.NewThreeColumnCsv(f, relatedness.Options{
+				RelatednessColumns: []string{"Wang", "Ritland", "LynchLi"},
+				Aggregate:          tc.aggregate,
+			})
+			if got := c.Relatedness("I1", "I2"); math.Abs(float64(got-tc.exp)) > 1e-9 {
+				t.Errorf("Got %v by %q, Expected %v", got, tc.aggregate, tc.exp)
+			}
+		}
+	})
+	t.Run("Reading leaves gocsv settings alone", func(t *testing.T) {
+		f := tempCsv(t, "# Comment\nID1,ID2,Wang,Ritland\nI1,I2,0.4,0.6\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		defer func(fail bool) { gocsv.FailIfUnmatchedStructTags = fail }(gocsv.FailIfUnmatchedStructTags)
+		gocsv.FailIfUnmatchedStructTags = true // As set by the demographics reader
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{
+			RelatednessColumns: []string{"Wang", "Ritland"},
+			CSV:                util.CsvOptions{Comment: '#'},
+		})
+		if got := c.Relatedness("I1", "I2"); math.Abs(float64(got-0.5)) > 1e-9 {
+			t.Errorf("Got %v, Expected 0.5", got)
+		}
+		if !gocsv.FailIfUnmatchedStructTags {
+			t.Errorf("Expected gocsv.FailIfUnmatchedStructTags to be left set")
+		}
+	})
+	t.Run("Near zero relatedness is unrelated", func(t *testing.T) {
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,1e-9\nI1,I3,0.5\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{})
+		if d := c.RelDistance("I1", "I2"); d != relational.Unrelated {
+			t.Errorf("Got distance %d for relatedness 1e-9, Expected unrelated", d)
+		}
+		if d := c.RelDistance("I1", "I3"); d != relational.First {
+			t.Errorf("Got distance %d for relatedness 0.5, Expected first", d)
+		}
+	})
+	t.Run("Too distant pairs are counted once normalized", func(t *testing.T) {
+		hook := test.NewGlobal()
+		defer hook.Reset()
+		f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,2\nI1,I3,0.0025\n")
+		defer os.Remove(f.Name())
+		defer f.Close()
+		c := relatedness.NewThreeColumnCsv(f, relatedness.Options{Normalize: true})
+		if d := c.RelDistance("I1", "I3"); d != relational.Unrelated {
+			t.Errorf("Got distance %d, Expected unrelated once halved", d)
+		}
+		warned := false
+		for _, e := range hook.AllEntries() {
+			warned = warned || strings.HasPrefix(e.Message, "1 relatedness values were above 0 but below ninth degree")
+		}
+		if !warned {
+			t.Errorf("Expected a warning for the pair made too distant by normalizing")
+		}
+	})
+	t.Run("Normalized maximum is first degree", func(t *testing.T) {
+		for _, opts := range []relatedness.Options{{Normalize: true}, {NormalizeToData: true}} {
+			f := tempCsv(t, "ID1,ID2,Rel\nI1,I2,1.5\nI1,I3,0.25\nI2,I3,-0.1\n")
+			defer os.Remove(f.Name())
+			defer f.Close()
+			c := relatedness.NewThreeColumnCsv(f, opts)
+			if got := c.Relatedness("I1", "I2"); got != 1 {
+				t.Errorf("Got %v with %+v, Expected 1", got, opts)
+			}
+			if got := c.RelDistance("I1", "I2"); got != relational.First {
+				t.Errorf("Got %v with %+v, Expected %v", got, opts, relational.First)
+			}
+		}
+	})
+}
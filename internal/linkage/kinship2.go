@@ -0,0 +1,67 @@
+package linkage
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/demographics"
+)
+
+// kinship2Missing marks an unknown parent for kinship2
+const kinship2Missing = "NA"
+
+// WriteKinship2 writes the known individuals of g, and any parents they
+// name, as a CSV of id, dadid, momid, and sex (1 male, 2 female, 3
+// unknown) to read into R for kinship2::pedigree(). Parents are found
+// as for Write. As kinship2 needs both parents or neither, individuals
+// with one parent are given a founder for the other, named by the
+// individual followed by "_father" or "_mother", and parents of unknown
+// sex are given the sex of their role.
+func WriteKinship2(w io.Writer, g *graph.Graph) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "dadid", "momid", "sex"})
+	rows := pedigreeRows(g)
+	// kinship2 also needs fathers male and mothers female
+	parentSex := make(map[string]demographics.Sex)
+	for _, r := range rows {
+		parentSex[r.father] = demographics.Male
+		parentSex[r.mother] = demographics.Female
+	}
+	var placeholders [][]string
+	for _, r := range rows {
+		sex := r.sex
+		if sex == demographics.Unknown {
+			sex = parentSex[r.id]
+		}
+		father, mother := r.father, r.mother
+		switch {
+		case father == "" && mother == "":
+			father, mother = kinship2Missing, kinship2Missing
+		case father == "":
+			father = r.id + "_father"
+			placeholders = append(placeholders, []string{father, kinship2Missing, kinship2Missing, kinship2Sex(demographics.Male)})
+		case mother == "":
+			mother = r.id + "_mother"
+			placeholders = append(placeholders, []string{mother, kinship2Missing, kinship2Missing, kinship2Sex(demographics.Female)})
+		}
+		cw.Write([]string{r.id, father, mother, kinship2Sex(sex)})
+	}
+	for _, record := range placeholders {
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// kinship2Sex is the kinship2 code for sex
+func kinship2Sex(sex demographics.Sex) string {
+	switch sex {
+	case demographics.Male:
+		return "1"
+	case demographics.Female:
+		return "2"
+	default:
+		return "3"
+	}
+}
@@ -0,0 +1,41 @@
+package linkage_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/demographics"
+	"github.com/rhagenson/relped/internal/linkage"
+)
+
+func TestWriteKinship2(t *testing.T) {
+	t.Run("Individuals have both parents or neither", func(t *testing.T) {
+		g := graph.NewGraph([]string{"Child", "Dad", "Mom", "Other"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"Child", "Dad"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"Child", "Mom"}, 1))
+		g.AddAge("Child", demographics.Age(2))
+		g.AddAge("Dad", demographics.Age(30))
+		g.AddSex("Dad", demographics.Male)
+		g.AddAge("Mom", demographics.Age(28))
+		g.AddSex("Mom", demographics.Female)
+		g.AddNodeNamed("Other")
+		g.AddDam("Other", "Absent")
+		out := new(strings.Builder)
+		if err := linkage.WriteKinship2(out, g); err != nil {
+			t.Fatalf("Could not write kinship2 CSV: %s", err)
+		}
+		expected := strings.Join([]string{
+			"id,dadid,momid,sex",
+			"Dad,NA,NA,1",
+			"Mom,NA,NA,2",
+			"Child,Dad,Mom,3",
+			"Absent,NA,NA,2",
+			"Other,Other_father,Absent,3",
+			"Other_father,NA,NA,1",
+		}, "\n") + "\n"
+		if out.String() != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+// Package linkage writes a pedigree graph as a table of individuals and
+// their parents, in the LINKAGE .ped format or for kinship2
+package linkage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/demographics"
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// missing marks an unknown parent in a .ped file
+const missing = "0"
+
+// row is one individual of a .ped file
+type row struct {
+	family, id, father, mother string
+	sex                        demographics.Sex
+}
+
+// Write writes the known individuals of g, and any parents they name,
+// as the first six columns of LINKAGE .ped: family, individual, father,
+// mother, sex (1 male, 2 female, 0 unknown), and affection (0 unknown).
+// Genotypes are not written, as relped does not read markers.
+func Write(w io.Writer, g *graph.Graph) error {
+	for _, r := range pedigreeRows(g) {
+		fields := []string{r.family, r.id, orMissing(r.father), orMissing(r.mother), sexCode(r.sex), missing}
+		for _, field := range fields {
+			if strings.IndexFunc(field, unicode.IsSpace) != -1 {
+				return fmt.Errorf("%q contains white space, which .ped cannot hold", field)
+			}
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pedigreeRows are the known individuals of g and any parents they name,
+// founders first within each family so parents come before offspring.
+// Parents are the sire and dam given as parentage, or else an older
+// known individual of known sex linked directly to the child, a best
+// guess as links do not say which individual is the parent.
+// Families are the family given in IDs, or else numbered by component.
+func pedigreeRows(g *graph.Graph) []*row {
+	rows := make(map[string]*row)
+	families := componentFamilies(g)
+	var names []string
+	nodes := g.Nodes()
+	for nodes.Next() {
+		if name, ok := g.IDToName(nodes.Node().ID()); ok && g.IsKnown(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		info := g.Info(name)
+		r := &row{family: families[name], id: name, father: info.Sire, mother: info.Dam, sex: info.Sex}
+		if family, ok := info.Field("family"); ok {
+			r.family = family
+		}
+		if r.father == "" || r.mother == "" {
+			inferParents(g, r)
+		}
+		rows[name] = r
+	}
+
+	// Parents must be listed as individuals too
+	for _, name := range names {
+		r := rows[name]
+		for parent, sex := range map[string]demographics.Sex{r.father: demographics.Male, r.mother: demographics.Female} {
+			if _, ok := rows[parent]; parent != "" && !ok {
+				rows[parent] = &row{family: r.family, id: parent, sex: sex}
+			}
+		}
+	}
+
+	ordered := make([]*row, 0, len(rows))
+	for _, r := range rows {
+		ordered = append(ordered, r)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		ri, rj := ordered[i], ordered[j]
+		if ri.family != rj.family {
+			return ri.family < rj.family
+		}
+		if fi, fj := ri.founder(), rj.founder(); fi != fj {
+			return fi
+		}
+		return ri.id < rj.id
+	})
+	return ordered
+}
+
+func (r *row) founder() bool {
+	return r.father == "" && r.mother == ""
+}
+
+// inferParents fills the missing parents of r with older knowns of
+// known sex linked directly to it, in order of name
+func inferParents(g *graph.Graph, r *row) {
+	age, ok := g.Info(r.id).Field("age")
+	if !ok {
+		return
+	}
+	childAge, _ := strconv.Atoi(age)
+	var linked []string
+	nodes := g.FromNamed(r.id)
+	for nodes.Next() {
+		if name, ok := g.IDToName(nodes.Node().ID()); ok && g.IsKnown(name) {
+			linked = append(linked, name)
+		}
+	}
+	sort.Strings(linked)
+	for _, name := range linked {
+		info := g.Info(name)
+		if _, ok := info.Field("age"); !ok || int(info.Age) <= childAge {
+			continue
+		}
+		switch {
+		case info.Sex == demographics.Male && r.father == "":
+			r.father = name
+		case info.Sex == demographics.Female && r.mother == "":
+			r.mother = name
+		}
+	}
+}
+
+// componentFamilies numbers each connected component of g from one,
+// in order of the first name in each
+func componentFamilies(g *graph.Graph) map[string]string {
+	var components [][]string
+	for _, component := range topo.ConnectedComponents(g) {
+		var names []string
+		for _, node := range component {
+			if name, ok := g.IDToName(node.ID()); ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		components = append(components, names)
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	families := make(map[string]string)
+	for i, names := range components {
+		for _, name := range names {
+			families[name] = strconv.Itoa(i + 1)
+		}
+	}
+	return families
+}
+
+func orMissing(id string) string {
+	if id == "" {
+		return missing
+	}
+	return id
+}
+
+// sexCode is the .ped code for sex
+func sexCode(sex demographics.Sex) string {
+	switch sex {
+	case demographics.Male:
+		return "1"
+	case demographics.Female:
+		return "2"
+	default:
+		return "0"
+	}
+}
@@ -0,0 +1,46 @@
+package linkage_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/io/demographics"
+	"github.com/rhagenson/relped/internal/linkage"
+)
+
+func TestWrite(t *testing.T) {
+	t.Run("Parents are given or inferred", func(t *testing.T) {
+		g := graph.NewGraph([]string{"Child", "Dad", "Mom", "Other"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"Child", "Dad"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"Child", "Mom"}, 1))
+		g.AddAge("Child", demographics.Age(2))
+		g.AddAge("Dad", demographics.Age(30))
+		g.AddSex("Dad", demographics.Male)
+		g.AddAge("Mom", demographics.Age(28))
+		g.AddSex("Mom", demographics.Female)
+		g.AddNodeNamed("Other")
+		g.AddDam("Other", "Absent")
+		out := new(strings.Builder)
+		if err := linkage.Write(out, g); err != nil {
+			t.Fatalf("Could not write PED: %s", err)
+		}
+		expected := strings.Join([]string{
+			"1 Dad 0 0 1 0",
+			"1 Mom 0 0 2 0",
+			"1 Child Dad Mom 0 0",
+			"2 Absent 0 0 2 0",
+			"2 Other 0 Absent 0 0",
+		}, "\n") + "\n"
+		if out.String() != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+		}
+	})
+	t.Run("White space in IDs is an error", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I 1"})
+		g.AddNodeNamed("I 1")
+		if err := linkage.Write(new(strings.Builder), g); err == nil {
+			t.Errorf("expected an error writing %q", "I 1")
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package pedigree
+
+import (
+	"fmt"
+
+	"github.com/rhagenson/relped/internal/graph"
+)
+
+// Colors of relationships in a drawn Diff
+const (
+	addedColor   = "forestgreen"
+	removedColor = "red"
+	changedColor = "orange"
+)
+
+// Diff draws changes between two pedigrees as a graph of the knowns
+// involved, linking pairs related only in the second pedigree in green,
+// only in the first in red, and at another distance in orange, each
+// labeled with the distance before and after
+func Diff(changes []graph.Change) string {
+	g := newDotGraph("diff")
+	g.directed = false
+	g.attrs["overlap"] = "false"
+	for _, c := range changes {
+		g.addNode(c.ID1, knownIndvAttrs)
+		g.addNode(c.ID2, knownIndvAttrs)
+		color := changedColor
+		switch {
+		case c.From == 0:
+			color = addedColor
+		case c.To == 0:
+			color = removedColor
+		}
+		g.addEdge(c.ID1, c.ID2, map[string]string{
+			"color": color,
+			"label": fmt.Sprintf("%s → %s", diffDistance(c.From), diffDistance(c.To)),
+		})
+	}
+	return g.String()
+}
+
+// diffDistance writes dist, or NA for unrelated pairs
+func diffDistance(dist uint) string {
+	if dist == 0 {
+		return "NA"
+	}
+	return fmt.Sprint(dist)
+}
@@ -0,0 +1,206 @@
+package pedigree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// dotGraph is a minimal Graphviz DOT graph that writes nodes and edges
+// in the order they were added and attributes in sorted order,
+// so the same pedigree is always written the same way
+type dotGraph struct {
+	name      string
+	directed  bool
+	attrs     map[string]string
+	nodes     []string
+	nodeAttrs map[string]map[string]string
+	edges     []dotEdge
+
+	// keep, when set, limits node and edge attributes to those named
+	keep map[string]bool
+}
+
+type dotEdge struct {
+	src, dst string
+	attrs    map[string]string
+}
+
+func newDotGraph(name string) *dotGraph {
+	return &dotGraph{
+		name:      name,
+		directed:  true,
+		attrs:     make(map[string]string),
+		nodeAttrs: make(map[string]map[string]string),
+	}
+}
+
+// addNode adds the node, or updates the attributes of an existing node
+func (g *dotGraph) addNode(name string, attrs map[string]string) {
+	if _, ok := g.nodeAttrs[name]; !ok {
+		g.nodes = append(g.nodes, name)
+		g.nodeAttrs[name] = make(map[string]string, len(attrs))
+	}
+	for attr, val := range attrs {
+		if g.keeps(attr) {
+			g.nodeAttrs[name][attr] = val
+		}
+	}
+}
+
+func (g *dotGraph) addEdge(src, dst string, attrs map[string]string) {
+	copied := make(map[string]string, len(attrs))
+	for attr, val := range attrs {
+		if g.keeps(attr) {
+			copied[attr] = val
+		}
+	}
+	g.edges = append(g.edges, dotEdge{src: src, dst: dst, attrs: copied})
+}
+
+// freePrefix is prefix, lengthened by underscores until none of the n
+// names prefix_0 through prefix_(n-1) is already a node of g, so nodes
+// named from it do not merge with individuals of the same name
+func (g *dotGraph) freePrefix(prefix string, n int) string {
+	taken := make(map[string]bool, len(g.nodes)+2*len(g.edges))
+	for _, node := range g.nodes {
+		taken[node] = true
+	}
+	for _, e := range g.edges {
+		taken[e.src] = true
+		taken[e.dst] = true
+	}
+	for ; ; prefix += "_" {
+		free := true
+		for i := 0; i < n && free; i++ {
+			free = !taken[fmt.Sprintf("%s_%d", prefix, i)]
+		}
+		if free {
+			return prefix
+		}
+	}
+}
+
+func (g *dotGraph) keeps(attr string) bool {
+	return g.keep == nil || g.keep[attr]
+}
+
+// header opens the graph and writes graph attributes
+func (g *dotGraph) header() string {
+	b := new(strings.Builder)
+	kind := "graph"
+	if g.directed {
+		kind = "digraph"
+	}
+	fmt.Fprintf(b, "%s %s {\n", kind, dotID(g.name))
+	for _, attr := range sortedKeys(g.attrs) {
+		fmt.Fprintf(b, "\t%s=%s;\n", dotID(attr), dotID(g.attrs[attr]))
+	}
+	return b.String()
+}
+
+// body writes nodes then edges
+func (g *dotGraph) body() string {
+	b := new(strings.Builder)
+	for _, node := range g.nodes {
+		fmt.Fprintf(b, "\t%s%s;\n", quoteID(node), dotAttrs(g.nodeAttrs[node]))
+	}
+	op := "--"
+	if g.directed {
+		op = "->"
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(b, "\t%s%s%s%s;\n", quoteID(e.src), op, quoteID(e.dst), dotAttrs(e.attrs))
+	}
+	return b.String()
+}
+
+func (g *dotGraph) String() string {
+	return g.header() + g.body() + "}\n"
+}
+
+func dotAttrs(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(attrs))
+	for _, attr := range sortedKeys(attrs) {
+		pairs = append(pairs, dotID(attr)+"="+dotID(attrs[attr]))
+	}
+	return " [ " + strings.Join(pairs, ", ") + " ]"
+}
+
+// dotID quotes id unless it is already a valid DOT ID
+// Quoted strings and HTML strings are left as they are
+func dotID(id string) string {
+	switch {
+	case id == "":
+		return `""`
+	case 2 <= len(id) && strings.HasPrefix(id, `"`) && strings.HasSuffix(id, `"`):
+		return id
+	case strings.HasPrefix(id, "<") && strings.HasSuffix(id, ">"):
+		return id
+	case isDotName(id) || isDotNumeral(id):
+		return id
+	default:
+		return `"` + strings.ReplaceAll(id, `"`, `\"`) + `"`
+	}
+}
+
+// quoteID writes name, an ID or other value from input, as a DOT ID
+// Unlike dotID, names are never taken as already quoted or HTML, and
+// backslashes are escaped so Graphviz does not read them as escapes
+func quoteID(name string) string {
+	if name != "" && (isDotName(name) || isDotNumeral(name)) {
+		return name
+	}
+	return quoted(name)
+}
+
+// quoted writes s as a quoted DOT string, escaping backslashes and quotes
+// and replacing invalid UTF-8, which Graphviz rejects
+func quoted(s string) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// dotKeywords cannot be used as unquoted IDs, regardless of case
+var dotKeywords = map[string]bool{
+	"node": true, "edge": true, "graph": true,
+	"digraph": true, "subgraph": true, "strict": true,
+}
+
+func isDotName(id string) bool {
+	if dotKeywords[strings.ToLower(id)] {
+		return false
+	}
+	for i, r := range id {
+		if !(r == '_' || unicode.IsLetter(r) || (0 < i && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDotNumeral(id string) bool {
+	s := strings.TrimPrefix(id, "-")
+	if s == "" || s == "." || strings.Count(s, ".") > 1 {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '.' || ('0' <= r && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,46 @@
+package pedigree
+
+import (
+	"fmt"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+// NewInducedPedigree draws only the knowns in g, linking every pair
+// connected in g by the relational distance of the shortest path between
+// them, named by labels when given
+// Pairs linked directly in g are drawn bold, those linked through others
+// dashed
+func NewInducedPedigree(g *graph.Graph, labels map[relational.Degree]string) *Pedigree {
+	ped := NewPedigree()
+	ped.g.name = "induced"
+	ped.g.directed = false
+	delete(ped.g.attrs, "splines") // Every pair may be linked, too many for ortho
+
+	names, dists := g.Distances()
+	for _, name := range names {
+		if g.NodeNamed(name) != nil {
+			ped.AddKnownIndv(name, g.Info(name).Sex)
+		}
+	}
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			if dists[i][j] == 0 {
+				continue
+			}
+			dist := relational.Degree(dists[i][j])
+			attrs := map[string]string{"style": "dashed"}
+			if g.HasEdgeBetweenNamed(names[i], names[j]) {
+				attrs["style"] = "bold"
+			}
+			if name, ok := labels[dist]; ok {
+				attrs["label"] = quoted(name)
+			} else {
+				attrs["label"] = quoted(fmt.Sprintf("distance %d", dist))
+			}
+			ped.g.addEdge(names[i], names[j], attrs)
+		}
+	}
+	return ped
+}
@@ -0,0 +1,183 @@
+package pedigree
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Layout is where Graphviz placed each individual of a pedigree,
+// in inches from the bottom left
+type Layout struct {
+	Width, Height float64
+	Nodes         []Position
+	Edges         [][2]string // Tail and head of each link, as drawn
+}
+
+// Position is the center and size of an individual in a Layout
+type Position struct {
+	ID                  string
+	X, Y, Width, Height float64
+}
+
+// ReadLayout reads the layout Graphviz writes with -Tplain
+func ReadLayout(r io.Reader) (*Layout, error) {
+	layout := new(Layout)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		s := &dotScanner{text: strings.TrimSpace(scanner.Text())}
+		if s.text == "" {
+			continue
+		}
+		kind, _, err := s.id()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		switch kind {
+		case "graph":
+			// graph scale width height
+			vals, err := s.floats(3)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			layout.Width, layout.Height = vals[1], vals[2]
+		case "node":
+			// node name x y width height label style shape color fillcolor
+			s.space()
+			id, _, err := s.id()
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			vals, err := s.floats(4)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			layout.Nodes = append(layout.Nodes, Position{ID: id, X: vals[0], Y: vals[1], Width: vals[2], Height: vals[3]})
+		case "edge":
+			// edge tail head n x1 y1 ... xn yn [label xl yl] style color
+			var ends [2]string
+			for i := range ends {
+				s.space()
+				if ends[i], _, err = s.id(); err != nil {
+					return nil, fmt.Errorf("line %d: %s", line, err)
+				}
+			}
+			layout.Edges = append(layout.Edges, ends)
+		case "stop":
+			return layout, nil
+		default:
+			return nil, fmt.Errorf("line %d: unexpected %q", line, kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+// floats reads the next n numbers
+func (s *dotScanner) floats(n int) ([]float64, error) {
+	vals := make([]float64, n)
+	for i := range vals {
+		s.space()
+		tok, _, err := s.id()
+		if err != nil {
+			return nil, err
+		}
+		if vals[i], err = strconv.ParseFloat(tok, 64); err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", tok)
+		}
+	}
+	return vals, nil
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the layout as GraphML, with the position and size
+// of each individual as x, y, width, and height data in inches
+func (layout *Layout) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphMLGraph{
+			ID:          "pedigree",
+			EdgeDefault: "directed",
+			Data: []graphMLData{
+				{Key: "graph-width", Value: formatInches(layout.Width)},
+				{Key: "graph-height", Value: formatInches(layout.Height)},
+			},
+		},
+	}
+	for _, name := range []string{"x", "y", "width", "height"} {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: name, For: "node", Name: name, Type: "double"})
+	}
+	for _, name := range []string{"width", "height"} {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "graph-" + name, For: "graph", Name: name, Type: "double"})
+	}
+	for _, pos := range layout.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: pos.ID,
+			Data: []graphMLData{
+				{Key: "x", Value: formatInches(pos.X)},
+				{Key: "y", Value: formatInches(pos.Y)},
+				{Key: "width", Value: formatInches(pos.Width)},
+				{Key: "height", Value: formatInches(pos.Height)},
+			},
+		})
+	}
+	for _, e := range layout.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e[0], Target: e[1]})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatInches(val float64) string {
+	return strconv.FormatFloat(val, 'f', -1, 64)
+}
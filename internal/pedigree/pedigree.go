@@ -2,12 +2,16 @@ package pedigree
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
-	"github.com/awalterschulze/gographviz"
 	mapset "github.com/deckarep/golang-set"
 	"github.com/rhagenson/relped/internal/graph"
 	"github.com/rhagenson/relped/internal/io/demographics"
+	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
 )
 
 // "Constant" maps for attributes
@@ -38,38 +42,156 @@ var (
 	}
 )
 
+// compactAttrs are the only attributes written by a compact pedigree,
+// those needed to tell sexes, unknowns, and direction apart
+var compactAttrs = map[string]bool{
+	"shape":       true,
+	"label":       true,
+	"peripheries": true,
+	"dir":         true,
+}
+
+// Sizes beyond which dot is known to fail, or overlap edges,
+// when routing with orthogonal splines
+const (
+	orthoMaxNodes = 500
+	orthoMaxEdges = 1000
+)
+
+// LayoutEngines are the Graphviz engines accepted by SetLayout
+var LayoutEngines = []string{"dot", "neato", "fdp", "sfdp", "twopi", "circo"}
+
+// IsLayoutEngine reports whether name is one of LayoutEngines
+func IsLayoutEngine(name string) bool {
+	for _, engine := range LayoutEngines {
+		if name == engine {
+			return true
+		}
+	}
+	return false
+}
+
+// Options alter how a Pedigree is drawn from a Graph
+type Options struct {
+	Undirected bool   // Draw relationships as simple lines
+	ClusterBy  string // Info field used to box known individuals together
+
+	// UnknownLabel labels unknown individuals, which are otherwise blank
+	UnknownLabel string
+	// RankBy is the Info field used to align known individuals on the
+	// same rank, in place of age
+	RankBy string
+	// Inbred marks known individuals with their relatedness to themselves
+	Inbred map[string]float64
+	// InferDirectionFrom is the Info field used to direct relationships
+	// between known individuals from higher (older) to lower values;
+	// all other relationships are drawn without arrows
+	InferDirectionFrom string
+	// Compact drops styling to minimize the size of the output
+	Compact bool
+	// ColorBy is the Info field used to fill known individuals sharing
+	// a value with the same color
+	ColorBy string
+	// Legend adds a key of the colors used by ColorBy and ColorByDistance
+	Legend bool
+	// ColorByDistance colors relationships from red to light blue
+	// by the relational distance of the closest relationship they draw
+	ColorByDistance bool
+	// WidthBySupport widens relationships by how many pairs of known
+	// individuals have their shortest path through them
+	WidthBySupport bool
+	// RelationshipLabels labels relationships with the name of their
+	// relational distance, such as RelationshipNames
+	RelationshipLabels map[relational.Degree]string
+	// Tooltips describes each individual and relationship in a tooltip,
+	// shown on hover in SVG output
+	Tooltips bool
+	// GenerationGap is the difference in age, in years, from which first
+	// degree relationships between knowns of known age are drawn as
+	// parent-offspring rather than full siblings; zero does not classify
+	GenerationGap uint
+	// Precision is the decimal places of numbers in tooltips
+	Precision util.Precision
+}
+
+// RelationshipNames are the relationships found at each relational distance
+var RelationshipNames = map[relational.Degree]string{
+	relational.First:   "parent/offspring or full sibling",
+	relational.Second:  "grandparent, half sibling, or avuncular",
+	relational.Third:   "great-grandparent, first cousin, or great-avuncular",
+	relational.Fourth:  "first cousin once removed or half first cousin",
+	relational.Fifth:   "second cousin or first cousin twice removed",
+	relational.Sixth:   "second cousin once removed",
+	relational.Seventh: "third cousin or second cousin twice removed",
+	relational.Eighth:  "third cousin once removed",
+	relational.Ninth:   "fourth cousin or third cousin twice removed",
+}
+
 type Pedigree struct {
-	g     *gographviz.Escape
-	ranks map[demographics.Age][]string
+	g        *dotGraph
+	ranks    map[demographics.Age][]string
+	clusters map[string][]string
+
+	unknownLabel string
+	rankBy       string
+	precision    util.Precision
+	cohorts      map[string][]string
+
+	colorBy        string
+	legend         [][2]string // Value and color pairs
+	distanceLegend [][2]string
 }
 
 func NewPedigree() *Pedigree {
-	g := gographviz.NewEscape()
-	g.SetDir(true)
-	g.SetName("pedigree")
+	g := newDotGraph("pedigree")
 	for attr, val := range graphAttrs {
-		g.AddAttr("pedigree", attr, val)
+		g.attrs[attr] = val
 	}
 	return &Pedigree{
-		g:     g,
-		ranks: make(map[demographics.Age][]string),
+		g:        g,
+		ranks:    make(map[demographics.Age][]string),
+		clusters: make(map[string][]string),
+		cohorts:  make(map[string][]string),
 	}
 }
 
-func NewPedigreeFromGraph(g *graph.Graph, indvs []string, undirected bool) (*Pedigree, []string) {
+func NewPedigreeFromGraph(g *graph.Graph, indvs []string, opts Options) (*Pedigree, []string) {
 	ped := NewPedigree()
-	if undirected {
-		ped.g.SetDir(false)
+	if opts.Undirected {
+		ped.g.directed = false
 	}
+	if opts.Compact {
+		ped.Compact()
+	}
+	ped.unknownLabel = opts.UnknownLabel
+	ped.precision = opts.Precision
+	ped.rankBy = opts.RankBy
 	mapped := mapset.NewSet()
 	var unmapped []string
 
+	// Visit edges by name so the same graph is always drawn the same
+	var edges [][2]string
 	iter := g.Edges()
 	for iter.Next() {
 		e := iter.Edge()
-
 		from, _ := g.IDToName(e.From().ID())
 		to, _ := g.IDToName(e.To().ID())
+		if to < from {
+			from, to = to, from
+		}
+		edges = append(edges, [2]string{from, to})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+
+	distances := make(map[relational.Degree]bool)
+	for _, e := range edges {
+		from, to := e[0], e[1]
+		nEdges := len(ped.g.edges)
 		fromKnown := g.IsKnown(from)
 		toKnown := g.IsKnown(to)
 		if fromKnown {
@@ -100,21 +222,89 @@ func NewPedigreeFromGraph(g *graph.Graph, indvs []string, undirected bool) (*Ped
 				ped.AddKnownRel(from, to)
 			case g.Info(to).Sire == from:
 				ped.AddKnownRel(from, to)
+			case firstDegreeKind(g, from, to, opts.GenerationGap) == fullSiblings:
+				ped.g.addEdge(from, to, undirected(knownRelAttrs))
+			case opts.InferDirectionFrom != "":
+				if parent, child, ok := inferDirection(g, opts.InferDirectionFrom, from, to); ok {
+					ped.AddKnownRel(parent, child)
+				} else {
+					ped.g.addEdge(from, to, undirected(knownRelAttrs))
+				}
 			case g.Info(from).Age > g.Info(to).Age:
 				ped.AddKnownRel(from, to)
 			default:
 				ped.AddKnownRel(to, from)
 			}
+		} else if opts.InferDirectionFrom != "" {
+			ped.g.addEdge(from, to, undirected(unknownRelAttrs))
 		} else {
 			ped.AddUnknownRel(from, to)
 		}
+		if dist, ok := g.EdgeDistance(from, to); ok {
+			var labels []string
+			if g.IsSummarized(from, to) {
+				labels = append(labels, fmt.Sprintf("distance %d", dist))
+			}
+			if kind := firstDegreeKind(g, from, to, opts.GenerationGap); kind != "" {
+				labels = append(labels, kind)
+			} else if name, ok := opts.RelationshipLabels[dist]; ok {
+				labels = append(labels, name)
+			}
+			for i := nEdges; i < len(ped.g.edges) && len(labels) != 0; i++ {
+				ped.g.edges[i].attrs["label"] = quoted(strings.Join(labels, "; "))
+			}
+		}
+		if carried := g.Carried(from, to); len(carried) != 0 && ped.g.keeps("comment") {
+			for i := nEdges; i < len(ped.g.edges); i++ {
+				ped.g.edges[i].attrs["comment"] = quoted(strings.Join(carried, "; "))
+			}
+		}
+		if n := g.Support(from, to); n != 0 && opts.WidthBySupport && ped.g.keeps("penwidth") {
+			for i := nEdges; i < len(ped.g.edges); i++ {
+				ped.g.edges[i].attrs["penwidth"] = supportWidth(n)
+			}
+		}
+		if g.IsBridged(from, to) && ped.g.keeps("style") {
+			// Bridges are assumed, so are set apart from links through unknowns
+			for i := nEdges; i < len(ped.g.edges); i++ {
+				ped.g.edges[i].attrs["style"] = "dotted"
+			}
+		}
+		if opts.Tooltips && ped.g.keeps("tooltip") {
+			for i := nEdges; i < len(ped.g.edges); i++ {
+				ped.g.edges[i].attrs["tooltip"] = quoted(relTooltip(g, from, to, opts.RelationshipLabels, opts.Precision))
+			}
+		}
+		if dist, ok := g.EdgeDistance(from, to); ok && opts.ColorByDistance && ped.g.keeps("color") {
+			distances[dist] = true
+			for i := nEdges; i < len(ped.g.edges); i++ {
+				ped.g.edges[i].attrs["color"] = distanceColor(dist)
+			}
+		}
 	}
 
+	colors := make(map[string][]string)
 	for _, indv := range indvs {
+		if val, ok := g.Info(indv).Field(opts.ColorBy); ok && mapped.Contains(indv) {
+			colors[val] = append(colors[val], indv)
+		}
+		if val, ok := opts.Inbred[indv]; ok && mapped.Contains(indv) {
+			ped.MarkInbred(indv, val)
+		}
+		if opts.Tooltips && mapped.Contains(indv) && ped.g.keeps("tooltip") {
+			ped.g.addNode(indv, map[string]string{"tooltip": quoted(indvTooltip(g, indv, opts.Inbred, opts.Precision))})
+		}
 		if mapped.Contains(indv) {
-			if g.Info(indv).Age != 0 {
+			if opts.RankBy != "" {
+				if val, ok := g.Info(indv).Field(opts.RankBy); ok {
+					ped.AddToCohort(val, indv)
+				}
+			} else if g.Info(indv).Age != 0 {
 				ped.AddToRank(g.Info(indv).Age, indv)
 			}
+			if val, ok := g.Info(indv).Field(opts.ClusterBy); ok {
+				ped.AddToCluster(val, indv)
+			}
 		} else {
 			if unmapped == nil {
 				unmapped = make([]string, 0, len(indvs)-mapped.Cardinality())
@@ -122,9 +312,177 @@ func NewPedigreeFromGraph(g *graph.Graph, indvs []string, undirected bool) (*Ped
 			unmapped = append(unmapped, indv)
 		}
 	}
+	if opts.Legend {
+		for dist := relational.First; dist <= relational.Ninth; dist++ {
+			if distances[dist] {
+				ped.distanceLegend = append(ped.distanceLegend, [2]string{strconv.Itoa(int(dist)), distanceColor(dist)})
+			}
+		}
+	}
+	ped.colorBy = opts.ColorBy
+	for _, entry := range palette(colors) {
+		for _, indv := range colors[entry[0]] {
+			ped.g.addNode(indv, map[string]string{"fillcolor": entry[1]})
+		}
+		if opts.Legend {
+			ped.legend = append(ped.legend, entry)
+		}
+	}
 	return ped, unmapped
 }
 
+// Kinds of first degree relationship told apart by GenerationGap
+const (
+	parentOffspring = "parent/offspring"
+	fullSiblings    = "full siblings"
+)
+
+// firstDegreeKind classifies the direct first degree link between
+// knowns from and to by their difference in age, as parentOffspring
+// when at least gap years apart or else fullSiblings
+// Returns empty if gap is zero, either age is unknown, the link is
+// to an unknown, or it draws another relationship
+func firstDegreeKind(g *graph.Graph, from, to string, gap uint) string {
+	if gap == 0 || !g.IsKnown(from) || !g.IsKnown(to) {
+		return ""
+	}
+	if dist, ok := g.EdgeDistance(from, to); !ok || dist != relational.First {
+		return ""
+	}
+	a1, a2 := g.Info(from).Age, g.Info(to).Age
+	if a1 == 0 || a2 == 0 {
+		return ""
+	}
+	if a2 < a1 {
+		a1, a2 = a2, a1
+	}
+	if uint(a2-a1) < gap {
+		return fullSiblings
+	}
+	return parentOffspring
+}
+
+// indvTooltip describes the known individual by its information
+func indvTooltip(g *graph.Graph, indv string, inbred map[string]float64, precision util.Precision) string {
+	parts := []string{indv}
+	for _, field := range graph.InfoFields {
+		if val, ok := g.Info(indv).Field(field); ok {
+			parts = append(parts, strings.Title(field)+": "+val)
+		}
+	}
+	if self, ok := inbred[indv]; ok {
+		parts = append(parts, "Self-relatedness: "+util.FormatFloat(self, precision))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// relTooltip describes the relationship drawn by the link from--to,
+// naming distances by labels or else RelationshipNames
+func relTooltip(g *graph.Graph, from, to string, labels map[relational.Degree]string, precision util.Precision) string {
+	var parts []string
+	if dist, ok := g.EdgeDistance(from, to); ok {
+		name, ok := labels[dist]
+		if !ok {
+			name = RelationshipNames[dist]
+		}
+		parts = append(parts,
+			fmt.Sprintf("Relational distance: %d (%s)", dist, name),
+			"Expected relatedness: "+util.FormatFloat(math.Pow(0.5, float64(dist)), precision),
+		)
+	}
+	if w, ok := g.WeightNamed(from, to); ok {
+		parts = append(parts, "Weight: "+util.FormatFloat(w, precision))
+	}
+	if n := g.Support(from, to); n != 0 {
+		parts = append(parts, fmt.Sprintf("Support: %d pairs", n))
+	}
+	if g.IsBridged(from, to) {
+		parts = append(parts, "Bridged by shared information, not relatedness")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// palette assigns each value a color of evenly spaced hue,
+// in order of value
+func palette(values map[string][]string) [][2]string {
+	names := make([]string, 0, len(values))
+	for val := range values {
+		names = append(names, val)
+	}
+	sort.Strings(names)
+	colors := make([][2]string, len(names))
+	for i, val := range names {
+		hue := float64(i) / float64(len(names))
+		colors[i] = [2]string{val, fmt.Sprintf("%.3f 0.400 1.000", hue)}
+	}
+	return colors
+}
+
+// inferDirection orders a and b from the higher to the lower numeric value
+// of field, returning false if either lacks a value or they are equal
+func inferDirection(g *graph.Graph, field, a, b string) (parent, child string, ok bool) {
+	aVal, aOk := g.Info(a).Field(field)
+	bVal, bOk := g.Info(b).Field(field)
+	if !aOk || !bOk {
+		return "", "", false
+	}
+	aNum, aErr := strconv.ParseFloat(aVal, 64)
+	bNum, bErr := strconv.ParseFloat(bVal, 64)
+	switch {
+	case aErr != nil || bErr != nil || aNum == bNum:
+		return "", "", false
+	case aNum > bNum:
+		return a, b, true
+	default:
+		return b, a, true
+	}
+}
+
+// distanceColor runs from red at first degree to light blue at ninth
+func distanceColor(dist relational.Degree) string {
+	frac := float64(dist-relational.First) / float64(relational.Ninth-relational.First)
+	return fmt.Sprintf("%.3f %.3f 1.000", 0.55*frac, 1-0.6*frac)
+}
+
+// supportWidth grows by one each time support doubles, from 1 for a
+// link on a single shortest path
+func supportWidth(support int) string {
+	return strconv.FormatFloat(1+math.Log2(float64(support)), 'f', 1, 64)
+}
+
+// undirected copies attrs, dropping the arrow head
+func undirected(attrs map[string]string) map[string]string {
+	copied := make(map[string]string, len(attrs)+1)
+	for attr, val := range attrs {
+		copied[attr] = val
+	}
+	copied["dir"] = "none"
+	return copied
+}
+
+// TooComplexForOrtho reports whether g is large enough that rendering
+// with orthogonal splines is likely to fail
+func TooComplexForOrtho(g *graph.Graph) bool {
+	return orthoMaxNodes < g.Nodes().Len() || orthoMaxEdges < g.Edges().Len()
+}
+
+// SimpleLayout drops the orthogonal splines in favor of Graphviz defaults
+func (p *Pedigree) SimpleLayout() {
+	delete(p.g.attrs, "splines")
+}
+
+// Compact drops graph attributes and any node or edge attributes added
+// later beyond those needed to read the pedigree
+func (p *Pedigree) Compact() {
+	p.g.attrs = make(map[string]string)
+	p.g.keep = compactAttrs
+}
+
+// SetLayout hints the Graphviz engine used to lay out the pedigree
+func (p *Pedigree) SetLayout(engine string) {
+	p.g.attrs["layout"] = engine
+}
+
 func (p *Pedigree) AddKnownIndv(node string, sex demographics.Sex) error {
 	attrs := knownIndvAttrs
 	switch sex {
@@ -138,35 +496,131 @@ func (p *Pedigree) AddKnownIndv(node string, sex demographics.Sex) error {
 		attrs["shape"] = "record"
 	}
 
-	return p.g.AddNode(p.g.Name, node, attrs)
+	p.g.addNode(node, attrs)
+	return nil
+}
+
+// MarkInbred draws a double border around the known individual node,
+// noting its relatedness to itself in a tooltip
+func (p *Pedigree) MarkInbred(node string, self float64) {
+	p.g.addNode(node, map[string]string{
+		"peripheries": "2",
+		"tooltip":     fmt.Sprintf("Self-relatedness: %s", util.FormatFloat(self, p.precision)),
+	})
 }
 
 func (p *Pedigree) AddUnknownIndv(node string) error {
 	attrs := unknownIndvAttrs
-	return p.g.AddNode(p.g.Name, node, attrs)
+	if p.unknownLabel != "" {
+		attrs = make(map[string]string, len(unknownIndvAttrs))
+		for attr, val := range unknownIndvAttrs {
+			attrs[attr] = val
+		}
+		attrs["label"] = p.unknownLabel
+	}
+	p.g.addNode(node, attrs)
+	return nil
 }
 
 func (p *Pedigree) AddKnownRel(src, dst string) error {
-	attrs := knownRelAttrs
-	return p.g.AddEdge(src, dst, p.g.Directed, attrs)
+	p.g.addEdge(src, dst, knownRelAttrs)
+	return nil
 }
 
 func (p *Pedigree) AddUnknownRel(src, dst string) error {
-	return p.g.AddEdge(src, dst, p.g.Directed, unknownRelAttrs)
+	p.g.addEdge(src, dst, unknownRelAttrs)
+	return nil
 }
 
 func (p *Pedigree) String() string {
-	out := p.g.String()
 	ranks := new(strings.Builder)
-	for age, indvs := range p.ranks {
-		if len(indvs) > 1 {
+	ages := make([]int, 0, len(p.ranks))
+	for age := range p.ranks {
+		ages = append(ages, int(age))
+	}
+	sort.Ints(ages)
+	for _, age := range ages {
+		if indvs := p.ranks[demographics.Age(age)]; len(indvs) > 1 {
 			ranks.WriteString("\t{rank=same; ")
-			ranks.WriteString(strings.Join(indvs, ", "))
+			ranks.WriteString(joinIDs(indvs, ", "))
 			ranks.WriteString(fmt.Sprintf(" }; // Age: %d\n", age))
 		}
 	}
-	out = out[:len(out)-2] + ranks.String() + "}\n"
-	return out
+	cohorts := make([]string, 0, len(p.cohorts))
+	for cohort := range p.cohorts {
+		cohorts = append(cohorts, cohort)
+	}
+	sort.Strings(cohorts)
+	for _, cohort := range cohorts {
+		if indvs := p.cohorts[cohort]; len(indvs) > 1 {
+			ranks.WriteString("\t{rank=same; ")
+			ranks.WriteString(joinIDs(indvs, ", "))
+			ranks.WriteString(fmt.Sprintf(" }; // %s: %s\n", p.rankBy, comment(cohort)))
+		}
+	}
+	clusters := new(strings.Builder)
+	names := make([]string, 0, len(p.clusters))
+	for name := range p.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		clusters.WriteString(fmt.Sprintf("\tsubgraph cluster_%d { label=%s; ", i, quoted(name)))
+		clusters.WriteString(joinIDs(p.clusters[name], "; "))
+		clusters.WriteString(" };\n")
+	}
+	if 0 < len(p.legend) {
+		prefix := p.g.freePrefix("legend", len(p.legend))
+		clusters.WriteString(fmt.Sprintf("\tsubgraph cluster_legend { label=%s; ", quoteID(p.colorBy)))
+		for i, entry := range p.legend {
+			clusters.WriteString(fmt.Sprintf("%s_%d [ label=%s, shape=box, style=filled, fillcolor=%s ]; ", prefix, i, quoteID(entry[0]), dotID(entry[1])))
+		}
+		clusters.WriteString("};\n")
+	}
+	if 0 < len(p.distanceLegend) {
+		prefix := p.g.freePrefix("distance_legend", len(p.distanceLegend))
+		clusters.WriteString("\tsubgraph cluster_distance_legend { label=\"Relational distance\"; ")
+		for i, entry := range p.distanceLegend {
+			clusters.WriteString(fmt.Sprintf("%s_%d [ label=%s, shape=box, style=filled, fillcolor=%s ]; ", prefix, i, dotID(entry[0]), dotID(entry[1])))
+		}
+		clusters.WriteString("};\n")
+	}
+	return p.g.header() + p.g.body() + ranks.String() + clusters.String() + "}\n"
+}
+
+// joinIDs joins names as DOT IDs with sep
+func joinIDs(names []string, sep string) string {
+	ids := make([]string, len(names))
+	for i, name := range names {
+		ids[i] = quoteID(name)
+	}
+	return strings.Join(ids, sep)
+}
+
+// comment makes s safe to write in a DOT line comment
+func comment(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// AddToCluster boxes id together with all others in the named cluster
+func (p *Pedigree) AddToCluster(name, id string) {
+	for _, indv := range p.clusters[name] {
+		if indv == id {
+			return
+		}
+	}
+	p.clusters[name] = append(p.clusters[name], id)
+}
+
+// AddToCohort aligns id on the same rank as all others
+// sharing the value of the RankBy field
+func (p *Pedigree) AddToCohort(value, id string) {
+	for _, indv := range p.cohorts[value] {
+		if indv == id {
+			return
+		}
+	}
+	p.cohorts[value] = append(p.cohorts[value], id)
 }
 
 func (p *Pedigree) AddToRank(a demographics.Age, id string) {
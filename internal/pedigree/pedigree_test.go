@@ -2,11 +2,14 @@ package pedigree_test
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/rhagenson/relped/internal/graph"
 	"github.com/rhagenson/relped/internal/io/demographics"
 	"github.com/rhagenson/relped/internal/pedigree"
+	"github.com/rhagenson/relped/internal/util"
 )
 
 // "Constant" maps for attributes
@@ -190,4 +193,411 @@ func TestPedigree(t *testing.T) {
 			t.Errorf("regex to find added ranks failed to compile")
 		}
 	})
+	t.Run("clusters are added properly", func(t *testing.T) {
+		p := pedigree.NewPedigree()
+		p.AddUnknownIndv("U1")
+		p.AddUnknownIndv("U2")
+		p.AddToCluster("FAM1", "U1")
+		p.AddToCluster("FAM1", "U2")
+		if pattern, err := regexp.Compile("subgraph cluster_.*"); err == nil {
+			line := pattern.FindString(p.String())
+			if line == "" {
+				t.Errorf("regex failed to match")
+			} else {
+				str := "{ label=\"FAM1\"; U1; U2 };"
+				if !strings.Contains(line, str) {
+					t.Errorf("expected %s in line: %s", str, line)
+				}
+			}
+		} else {
+			t.Errorf("regex to find added clusters failed to compile")
+		}
+	})
+	t.Run("unknown label replaces blank label", func(t *testing.T) {
+		p, _ := pedigree.NewPedigreeFromGraph(graph.NewGraph(nil), nil, pedigree.Options{UnknownLabel: "Anc"})
+		p.AddUnknownIndv("U1")
+		p.AddUnknownIndv("U2")
+		if line := regexp.MustCompile("U1.*").FindString(p.String()); !strings.Contains(line, "label=Anc") {
+			t.Errorf("expected %s in line: %s", "label=Anc", line)
+		}
+		if unknownIndvAttrs["label"] != "\"\"" {
+			t.Errorf("unknown label changed the default attributes")
+		}
+	})
+	t.Run("layout engine is added", func(t *testing.T) {
+		p := pedigree.NewPedigree()
+		p.SetLayout("sfdp")
+		if str := "layout=sfdp"; !strings.Contains(p.String(), str) {
+			t.Errorf("expected %s in: %s", str, p.String())
+		}
+	})
+	t.Run("simple layout drops orthogonal splines", func(t *testing.T) {
+		p := pedigree.NewPedigree()
+		p.SimpleLayout()
+		if str := "splines"; strings.Contains(p.String(), str) {
+			t.Errorf("expected no %s in: %s", str, p.String())
+		}
+		if str := "rankdir=TB"; !strings.Contains(p.String(), str) {
+			t.Errorf("expected %s in: %s", str, p.String())
+		}
+	})
+	t.Run("large graphs are too complex for orthogonal splines", func(t *testing.T) {
+		small := graph.NewGraph([]string{"I1", "I2"})
+		small.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		if pedigree.TooComplexForOrtho(small) {
+			t.Errorf("expected a two individual graph to be simple enough")
+		}
+		names := make([]string, 501)
+		for i := range names {
+			names[i] = "I" + strconv.Itoa(i)
+		}
+		large := graph.NewGraph(names)
+		large.AddPath(graph.NewEqualWeightPath(names, 1))
+		if !pedigree.TooComplexForOrtho(large) {
+			t.Errorf("expected a %d individual graph to be too complex", len(names))
+		}
+	})
+	t.Run("cohorts are ranked together", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		g.AddFamily("I1", "FAM1")
+		g.AddFamily("I2", "FAM1")
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2"}, pedigree.Options{RankBy: "family"})
+		line := regexp.MustCompile("{rank=same.*").FindString(p.String())
+		if str := "{rank=same; I1, I2 }; // family: FAM1"; !strings.Contains(line, str) {
+			t.Errorf("expected %s in line: %s", str, line)
+		}
+	})
+	t.Run("output is deterministic", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2", "U2", "I3"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I3"}, 1))
+		first, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{})
+		for i := 0; i < 10; i++ {
+			p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{})
+			if p.String() != first.String() {
+				t.Fatalf("expected the same output, got:\n%s\nthen:\n%s", first, p)
+			}
+		}
+	})
+	t.Run("inbred individuals have double borders", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2"}, pedigree.Options{Inbred: map[string]float64{"I1": 0.6}})
+		if line := regexp.MustCompile("I1 .*").FindString(p.String()); !strings.Contains(line, "peripheries=2") {
+			t.Errorf("expected %s in line: %s", "peripheries=2", line)
+		}
+		if line := regexp.MustCompile("I2 .*").FindString(p.String()); strings.Contains(line, "peripheries") {
+			t.Errorf("expected no peripheries in line: %s", line)
+		}
+	})
+	t.Run("direction is inferred from metadata", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "U1", "I3"}, 1))
+		g.AddAge("I1", 3)
+		g.AddAge("I2", 10)
+		g.AddAge("I3", 2)
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{InferDirectionFrom: "age"})
+		out := p.String()
+		if line := regexp.MustCompile("I2->I1.*").FindString(out); line == "" || strings.Contains(line, "dir=none") {
+			t.Errorf("expected directed I2->I1 in: %s", out)
+		}
+		for _, line := range regexp.MustCompile(".*U1.*->.*|.*->U1.*").FindAllString(out, -1) {
+			if !strings.Contains(line, "dir=none") {
+				t.Errorf("expected dir=none in line: %s", line)
+			}
+		}
+	})
+	t.Run("compact output drops styling", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "I2"}, 1))
+		g.AddSex("I1", demographics.Male)
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2"}, pedigree.Options{Compact: true})
+		out := p.String()
+		for _, str := range []string{"fontname", "style", "fillcolor", "splines", "rankdir"} {
+			if strings.Contains(out, str) {
+				t.Errorf("expected no %s in: %s", str, out)
+			}
+		}
+		for _, str := range []string{"I1 [ shape=box ]", `U1 [ label="", shape=diamond ]`} {
+			if !strings.Contains(out, str) {
+				t.Errorf("expected %s in: %s", str, out)
+			}
+		}
+	})
+	t.Run("individuals are colored by information", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2", "I3"}, 1))
+		g.AddFamily("I1", "FAM1")
+		g.AddFamily("I2", "FAM2")
+		g.AddFamily("I3", "FAM1")
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{ColorBy: "family", Legend: true})
+		out := p.String()
+		color := regexp.MustCompile(`fillcolor="[^"]*"`)
+		i1 := color.FindString(regexp.MustCompile("I1 .*").FindString(out))
+		i2 := color.FindString(regexp.MustCompile("I2 .*").FindString(out))
+		i3 := color.FindString(regexp.MustCompile("I3 .*").FindString(out))
+		if i1 == "" || i1 != i3 || i1 == i2 {
+			t.Errorf("expected I1 and I3 to share a color apart from I2, got %q, %q, %q", i1, i3, i2)
+		}
+		if !strings.Contains(out, "subgraph cluster_legend { label=family;") {
+			t.Errorf("expected a legend in: %s", out)
+		}
+	})
+	t.Run("legend entries do not take the names of individuals", func(t *testing.T) {
+		g := graph.NewGraph([]string{"legend_0", "legend_1"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"legend_0", "legend_1"}, 1))
+		g.AddFamily("legend_0", "FAM1")
+		g.AddFamily("legend_1", "FAM2")
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"legend_0", "legend_1"}, pedigree.Options{ColorBy: "family", Legend: true})
+		out := p.String()
+		if strings.Contains(out, "legend_0 [ label=") || !strings.Contains(out, "legend__0 [ label=FAM1") {
+			t.Errorf("expected legend entries apart from individuals in: %s", out)
+		}
+	})
+	t.Run("relationships are colored by distance", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "U1", "U2", "I3"}, 1))
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{ColorByDistance: true, Legend: true})
+		out := p.String()
+		color := regexp.MustCompile(`color="[^"]*"`)
+		first := color.FindString(regexp.MustCompile("I1->I2.*|I2->I1.*").FindString(out))
+		third := color.FindString(regexp.MustCompile(".*->U2.*|U2->.*").FindString(out))
+		if first == "" || third == "" || first == third {
+			t.Errorf("expected differing colors for first and third degree, got %q and %q in: %s", first, third, out)
+		}
+		if !strings.Contains(out, "cluster_distance_legend") {
+			t.Errorf("expected a distance legend in: %s", out)
+		}
+	})
+	t.Run("distance legend entries do not take the names of individuals", func(t *testing.T) {
+		g := graph.NewGraph([]string{"distance_legend_0", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"distance_legend_0", "I2"}, 1))
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"distance_legend_0", "I2"}, pedigree.Options{ColorByDistance: true, Legend: true})
+		out := p.String()
+		if strings.Contains(out, "distance_legend_0 [ label=") || !strings.Contains(out, "distance_legend__0 [ label=1") {
+			t.Errorf("expected distance legend entries apart from individuals in: %s", out)
+		}
+	})
+	t.Run("relationships are widened by support", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		g.Prune()
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{WidthBySupport: true})
+		out := p.String()
+		if !strings.Contains(out, "penwidth=2.0") {
+			t.Errorf("expected links on two shortest paths to be widened in: %s", out)
+		}
+		p, _ = pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{})
+		if strings.Contains(p.String(), "penwidth") {
+			t.Errorf("expected no widths unless asked in: %s", p)
+		}
+	})
+	t.Run("relationships are labeled by name", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		first, _ := graph.NewRelationalWeightPath("I1", "I2", 1, 1, graph.EqualScheme, "U")
+		g.AddPath(first)
+		fifth, _ := graph.NewCappedRelationalWeightPath("I2", "I3", 5, 1, graph.EqualScheme, "U", 1)
+		g.AddPath(fifth)
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2", "I3"}, pedigree.Options{RelationshipLabels: pedigree.RelationshipNames})
+		out := p.String()
+		for _, label := range []string{
+			`label="parent/offspring or full sibling"`,
+			`label="distance 5; second cousin or first cousin twice removed"`,
+		} {
+			if !strings.Contains(out, label) {
+				t.Errorf("expected %s in: %s", label, out)
+			}
+		}
+	})
+	t.Run("tooltips are written to the precision set", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 2))
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2"}, pedigree.Options{
+			Tooltips:  true,
+			Inbred:    map[string]float64{"I2": 0.6},
+			Precision: util.Places(2),
+		})
+		for _, str := range []string{"Weight: 2.00", "Self-relatedness: 0.60"} {
+			if !strings.Contains(p.String(), str) {
+				t.Errorf("expected %s in: %s", str, p)
+			}
+		}
+	})
+	t.Run("tooltips describe individuals and relationships", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		path, _ := graph.NewRelationalWeightPath("I1", "I2", 1, 2, graph.EqualScheme, "U")
+		g.AddPath(path)
+		g.AddSex("I1", demographics.Male)
+		g.AddAge("I1", 4)
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2"}, pedigree.Options{
+			Tooltips: true,
+			Inbred:   map[string]float64{"I2": 0.6},
+		})
+		for _, str := range []string{
+			"Relational distance: 1 (parent/offspring or full sibling); Expected relatedness: 0.5; Weight: 2",
+			"I1; Sex: " + demographics.Male.String() + "; Age: 4",
+			"I2; Self-relatedness: 0.6",
+		} {
+			if !strings.Contains(p.String(), `tooltip="`+str+`"`) {
+				t.Errorf("expected tooltip %s in: %s", str, p)
+			}
+		}
+	})
+	t.Run("first degree relatives are classified by age gap", func(t *testing.T) {
+		g := graph.NewGraph([]string{"Dad", "Kid", "Sib"})
+		for _, pair := range [][2]string{{"Dad", "Kid"}, {"Kid", "Sib"}} {
+			path, _ := graph.NewRelationalWeightPath(pair[0], pair[1], 1, 2, graph.EqualScheme, "U")
+			g.AddPath(path)
+		}
+		g.AddAge("Dad", 30)
+		g.AddAge("Kid", 5)
+		g.AddAge("Sib", 3)
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"Dad", "Kid", "Sib"}, pedigree.Options{GenerationGap: 15})
+		out := p.String()
+		for _, str := range []string{
+			`Dad->Kid [ label="parent/offspring", style=bold ]`,
+			`Kid->Sib [ dir=none, label="full siblings", style=bold ]`,
+		} {
+			if !strings.Contains(out, str) {
+				t.Errorf("expected %s in: %s", str, out)
+			}
+		}
+	})
+	t.Run("induced pedigree links every connected pair of knowns", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2", "I3"})
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "I2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		out := pedigree.NewInducedPedigree(g, nil).String()
+		for _, want := range []string{
+			`I1--I2 [ label="distance 1", style=bold ]`,
+			`I1--I3 [ label="distance 2", style=dashed ]`,
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Got %s, Expected %s", out, want)
+			}
+		}
+	})
+	t.Run("summarized links are labeled with their distance", func(t *testing.T) {
+		g := graph.NewGraph([]string{"I1", "I2"})
+		path, _ := graph.NewCappedRelationalWeightPath("I1", "I2", 5, 1, graph.EqualScheme, "U", 1)
+		g.AddPath(path)
+		p, _ := pedigree.NewPedigreeFromGraph(g, []string{"I1", "I2"}, pedigree.Options{})
+		if str := `label="distance 5"`; strings.Count(p.String(), str) != 1 {
+			t.Errorf("expected one %s in: %s", str, p)
+		}
+	})
+	t.Run("IDs are quoted as needed", func(t *testing.T) {
+		tt := []struct {
+			id, exp string
+		}{
+			{"I1", "\tI1 ["},
+			{"José", "\tJosé ["},
+			{"Ōkami_2", "\tŌkami_2 ["},
+			{"Ind 1", "\t\"Ind 1\" ["},
+			{`Ind "A"`, "\t\"Ind \\\"A\\\"\" ["},
+			{`Ind\n`, "\t\"Ind\\\\n\" ["},
+			{`"I2"`, "\t\"\\\"I2\\\"\" ["},
+			{"<I3>", "\t\"<I3>\" ["},
+			{"node", "\t\"node\" ["},
+			{"I-4", "\t\"I-4\" ["},
+		}
+		for _, tc := range tt {
+			p := pedigree.NewPedigree()
+			p.AddKnownIndv(tc.id, demographics.Unknown)
+			p.AddKnownIndv("I0", demographics.Unknown)
+			p.AddKnownRel(tc.id, "I0")
+			if !strings.Contains(p.String(), tc.exp) {
+				t.Errorf("expected %s for %q in: %s", tc.exp, tc.id, p)
+			}
+			if edge := strings.TrimSuffix(tc.exp, " [") + "->I0"; !strings.Contains(p.String(), edge) {
+				t.Errorf("expected %s for %q in: %s", edge, tc.id, p)
+			}
+		}
+	})
+	t.Run("pedigrees are read back", func(t *testing.T) {
+		indvs := []string{"I1", "Ind 2", "José", "node"}
+		g := graph.NewGraph(indvs)
+		g.AddPath(graph.NewEqualWeightPath([]string{"I1", "U1", "Ind 2"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"Ind 2", "José"}, 1))
+		g.AddPath(graph.NewEqualWeightPath([]string{"José", "U2", "U3", "node"}, 1))
+		g.AddFamily("I1", "FAM1")
+		g.AddFamily("José", "FAM1")
+		p, _ := pedigree.NewPedigreeFromGraph(g, indvs, pedigree.Options{ClusterBy: "family"})
+		read, err := pedigree.ReadGraph(strings.NewReader(p.String()))
+		if err != nil {
+			t.Fatalf("Could not read pedigree: %s", err)
+		}
+		expNames, expDists := g.Distances()
+		names, dists := read.Distances()
+		if strings.Join(names, ",") != strings.Join(expNames, ",") {
+			t.Fatalf("Got knowns %v, Expected %v", names, expNames)
+		}
+		for i := range dists {
+			for j := range dists[i] {
+				if dists[i][j] != expDists[i][j] {
+					t.Errorf("Got distance %d between %s and %s, Expected %d", dists[i][j], names[i], names[j], expDists[i][j])
+				}
+			}
+		}
+	})
+	t.Run("summarized links are read back at their distance", func(t *testing.T) {
+		indvs := []string{"I1", "I2", "I3"}
+		g := graph.NewGraph(indvs)
+		path, _ := graph.NewCappedRelationalWeightPath("I1", "I2", 5, 1, graph.EqualScheme, "U", 2)
+		g.AddPath(path)
+		g.AddPath(graph.NewEqualWeightPath([]string{"I2", "I3"}, 1))
+		p, _ := pedigree.NewPedigreeFromGraph(g, indvs, pedigree.Options{})
+		read, err := pedigree.ReadGraph(strings.NewReader(p.String()))
+		if err != nil {
+			t.Fatalf("Could not read pedigree: %s", err)
+		}
+		_, expDists := g.Distances()
+		names, dists := read.Distances()
+		for i := range dists {
+			for j := range dists[i] {
+				if dists[i][j] != expDists[i][j] {
+					t.Errorf("Got distance %d between %s and %s, Expected %d", dists[i][j], names[i], names[j], expDists[i][j])
+				}
+			}
+		}
+	})
+	t.Run("layouts are read from plain Graphviz output", func(t *testing.T) {
+		plain := "graph 1 2.5 3\n" +
+			"node I1 0.5 2.5 0.75 0.5 I1 solid box black lightgrey\n" +
+			"node \"Ind 2\" 2 0.5 0.75 0.5 \"Ind 2\" solid ellipse black lightgrey\n" +
+			"edge I1 \"Ind 2\" 4 0.5 2.2 0.8 1.5 1.5 1 2 0.8 solid black\n" +
+			"stop\n"
+		layout, err := pedigree.ReadLayout(strings.NewReader(plain))
+		if err != nil {
+			t.Fatalf("Could not read layout: %s", err)
+		}
+		if layout.Width != 2.5 || layout.Height != 3 {
+			t.Errorf("Got size %vx%v, Expected 2.5x3", layout.Width, layout.Height)
+		}
+		exp := pedigree.Position{ID: "Ind 2", X: 2, Y: 0.5, Width: 0.75, Height: 0.5}
+		if len(layout.Nodes) != 2 || layout.Nodes[1] != exp {
+			t.Errorf("Got %+v, Expected second node %+v", layout.Nodes, exp)
+		}
+		if len(layout.Edges) != 1 || layout.Edges[0] != [2]string{"I1", "Ind 2"} {
+			t.Errorf("Got edges %v, Expected I1 to Ind 2", layout.Edges)
+		}
+		out := new(strings.Builder)
+		if err := layout.WriteGraphML(out); err != nil {
+			t.Fatalf("Could not write GraphML: %s", err)
+		}
+		for _, str := range []string{
+			`<key id="x" for="node" attr.name="x" attr.type="double"></key>`,
+			`<node id="Ind 2">`,
+			`<data key="x">2</data>`,
+			`<edge source="I1" target="Ind 2"></edge>`,
+		} {
+			if !strings.Contains(out.String(), str) {
+				t.Errorf("expected %s in:\n%s", str, out)
+			}
+		}
+	})
 }
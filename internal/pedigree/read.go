@@ -0,0 +1,275 @@
+package pedigree
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rhagenson/relped/internal/graph"
+	"github.com/rhagenson/relped/internal/unit"
+	"github.com/rhagenson/relped/internal/unit/relational"
+)
+
+// ReadGraph reads a pedigree written by Pedigree.String back into a Graph,
+// taking individuals drawn as diamonds to be unknown. Weights are not
+// written with a pedigree, so every link is read with a weight of one.
+// Links labeled with a distance summarize the rest of a relationship,
+// so are read as the end of a chain of unknowns at that distance.
+func ReadGraph(r io.Reader) (*graph.Graph, error) {
+	var knowns, nodes []string
+	var edges [][2]string
+	summarized := make(map[[2]string]relational.Degree)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		s := &dotScanner{text: strings.TrimSpace(scanner.Text())}
+		if s.text == "" || strings.HasPrefix(s.text, "{") || strings.HasPrefix(s.text, "}") || strings.HasPrefix(s.text, "//") {
+			continue // Ranks and closing braces
+		}
+		id, quoted, err := s.id()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		if !quoted && dotKeywords[strings.ToLower(id)] {
+			continue // Graph header, clusters, and defaults
+		}
+		s.space()
+		switch {
+		case s.consume("->"), s.consume("--"):
+			s.space()
+			to, _, err := s.id()
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			edges = append(edges, [2]string{id, to})
+			attrs, err := s.attrs()
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			if dist, ok := summaryDistance(attrs["label"]); ok {
+				summarized[[2]string{id, to}] = dist
+			}
+		case s.consume("="):
+			continue // Graph attribute
+		default:
+			attrs, err := s.attrs()
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			nodes = append(nodes, id)
+			if attrs["shape"] != unknownIndvAttrs["shape"] {
+				knowns = append(knowns, id)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	g := graph.NewGraph(knowns)
+	for _, node := range nodes {
+		g.AddNodeNamed(node)
+	}
+	for _, e := range edges {
+		g.AddPath(graph.NewEqualWeightPath([]string{e[0], e[1]}, 1))
+	}
+	for _, e := range edges {
+		if dist, ok := summarized[e]; ok {
+			if chain := summaryChain(g, e); chain != nil {
+				g.AddPath(readPath{names: chain, dist: dist})
+			}
+		}
+	}
+	return g, nil
+}
+
+// summaryDistance is the distance in label, as written on summarized links
+// Returns false if label has no distance
+func summaryDistance(label string) (relational.Degree, bool) {
+	for _, part := range strings.Split(label, "; ") {
+		if !strings.HasPrefix(part, "distance ") {
+			continue
+		}
+		if dist, err := strconv.ParseUint(strings.TrimPrefix(part, "distance "), 10, 0); err == nil {
+			return relational.Degree(dist), true
+		}
+	}
+	return relational.Unrelated, false
+}
+
+// summaryChain is the chain of unknowns ending in the summarized link e,
+// from the closest other known through unknowns alone to the known of e
+// Returns nil if e does not join an unknown to a known
+func summaryChain(g *graph.Graph, e [2]string) []string {
+	unknown, known := e[0], e[1]
+	if g.IsKnown(unknown) {
+		unknown, known = known, unknown
+	}
+	if g.IsKnown(unknown) || !g.IsKnown(known) {
+		return nil
+	}
+	prev := map[string]string{unknown: ""}
+	queue := []string{unknown}
+	for len(queue) != 0 {
+		name := queue[0]
+		queue = queue[1:]
+		var next []string
+		nodes := g.FromNamed(name)
+		for nodes.Next() {
+			if n, ok := g.IDToName(nodes.Node().ID()); ok && n != known {
+				next = append(next, n)
+			}
+		}
+		sort.Strings(next)
+		for _, n := range next {
+			if _, ok := prev[n]; ok {
+				continue
+			}
+			prev[n] = name
+			if !g.IsKnown(n) {
+				queue = append(queue, n)
+				continue
+			}
+			var chain []string
+			for at := n; at != ""; at = prev[at] {
+				chain = append(chain, at)
+			}
+			return append(chain, known)
+		}
+	}
+	return nil
+}
+
+// readPath is a chain read back from a pedigree, at the distance
+// its summarized last link was labeled with
+type readPath struct {
+	names []string
+	dist  relational.Degree
+}
+
+func (p readPath) Names() []string {
+	return p.names
+}
+
+func (p readPath) Weights() []unit.Weight {
+	return graph.NewEqualWeightPath(p.names, 1).Weights()
+}
+
+func (p readPath) Distance() relational.Degree {
+	return p.dist
+}
+
+// dotScanner reads IDs and attributes from a line of DOT
+type dotScanner struct {
+	text string
+	pos  int
+}
+
+func (s *dotScanner) space() {
+	for s.pos < len(s.text) && (s.text[s.pos] == ' ' || s.text[s.pos] == '\t') {
+		s.pos++
+	}
+}
+
+// consume moves past tok if it is next, reporting whether it was
+func (s *dotScanner) consume(tok string) bool {
+	if strings.HasPrefix(s.text[s.pos:], tok) {
+		s.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+// id reads the next ID, unquoting it as written by quoteID
+func (s *dotScanner) id() (id string, quoted bool, err error) {
+	if len(s.text) <= s.pos {
+		return "", false, errors.New("expected an ID")
+	}
+	switch s.text[s.pos] {
+	case '"':
+		b := new(strings.Builder)
+		for i := s.pos + 1; i < len(s.text); i++ {
+			switch c := s.text[i]; {
+			case c == '\\' && i+1 < len(s.text) && (s.text[i+1] == '"' || s.text[i+1] == '\\'):
+				b.WriteByte(s.text[i+1])
+				i++
+			case c == '"':
+				s.pos = i + 1
+				return b.String(), true, nil
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", false, errors.New("unterminated quoted ID")
+	case '<':
+		depth := 0
+		for i := s.pos; i < len(s.text); i++ {
+			switch s.text[i] {
+			case '<':
+				depth++
+			case '>':
+				if depth--; depth == 0 {
+					id := s.text[s.pos : i+1]
+					s.pos = i + 1
+					return id, true, nil
+				}
+			}
+		}
+		return "", false, errors.New("unterminated HTML ID")
+	default:
+		start := s.pos
+		if s.text[s.pos] == '-' {
+			s.pos++
+		}
+		for s.pos < len(s.text) && isIDByte(s.text[s.pos]) {
+			s.pos++
+		}
+		if s.pos == start {
+			return "", false, fmt.Errorf("unexpected %q", s.text[s.pos:])
+		}
+		return s.text[start:s.pos], false, nil
+	}
+}
+
+// isIDByte reports whether c may be part of an unquoted name or numeral
+func isIDByte(c byte) bool {
+	return c == '_' || c == '.' || 0x80 <= c ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// attrs reads an optional attribute list
+func (s *dotScanner) attrs() (map[string]string, error) {
+	attrs := make(map[string]string)
+	s.space()
+	if !s.consume("[") {
+		return attrs, nil
+	}
+	for {
+		s.space()
+		if s.consume("]") {
+			return attrs, nil
+		}
+		key, _, err := s.id()
+		if err != nil {
+			return nil, err
+		}
+		s.space()
+		if !s.consume("=") {
+			return nil, fmt.Errorf("expected = after %s", key)
+		}
+		s.space()
+		val, _, err := s.id()
+		if err != nil {
+			return nil, err
+		}
+		attrs[key] = val
+		s.space()
+		if !s.consume(",") {
+			s.consume(";")
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package util
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// CsvOptions loosen how strictly CSV input is parsed
+type CsvOptions struct {
+	LazyQuotes       bool // Allow quotes to appear in unquoted fields
+	Comment          rune // Lines starting with Comment are ignored, zero disables
+	TrimLeadingSpace bool // Ignore leading white space in fields
+}
+
+// NewCsvReader creates a csv.Reader of in, loosened as set by opts
+func NewCsvReader(in io.Reader, opts CsvOptions) *csv.Reader {
+	r := csv.NewReader(in)
+	r.LazyQuotes = opts.LazyQuotes
+	r.Comment = opts.Comment
+	r.TrimLeadingSpace = opts.TrimLeadingSpace
+	return r
+}
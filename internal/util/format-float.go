@@ -0,0 +1,38 @@
+package util
+
+import (
+	"math"
+	"strconv"
+)
+
+// Precision is the decimal places FormatFloat and RoundFloat keep
+// The zero Precision keeps as many as needed
+type Precision struct {
+	places int
+	fixed  bool
+}
+
+// Places is the Precision of n decimal places, with -1 for as many as needed
+func Places(n int) Precision {
+	if n < 0 {
+		return Precision{}
+	}
+	return Precision{places: n, fixed: true}
+}
+
+// FormatFloat formats x with the decimal places of p
+func FormatFloat(x float64, p Precision) string {
+	if !p.fixed {
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(x, 'f', p.places, 64)
+}
+
+// RoundFloat rounds x to the decimal places of p
+func RoundFloat(x float64, p Precision) float64 {
+	if !p.fixed {
+		return x
+	}
+	scale := math.Pow(10, float64(p.places))
+	return math.Round(x*scale) / scale
+}
@@ -0,0 +1,33 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/rhagenson/relped/internal/util"
+)
+
+func TestFormatFloat(t *testing.T) {
+	tt := []struct {
+		name      string
+		precision int
+		x         float64
+		exp       string
+		expRound  float64
+	}{
+		{name: "Default keeps all places", precision: -1, x: 0.4873029, exp: "0.4873029", expRound: 0.4873029},
+		{name: "Rounds to places", precision: 2, x: 0.4873029, exp: "0.49", expRound: 0.49},
+		{name: "Pads to places", precision: 3, x: 0.5, exp: "0.500", expRound: 0.5},
+		{name: "Zero places", precision: 0, x: 2.5, exp: "2", expRound: 3},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := util.Places(tc.precision)
+			if got := util.FormatFloat(tc.x, p); got != tc.exp {
+				t.Errorf("Got %s, Expected %s", got, tc.exp)
+			}
+			if got := util.RoundFloat(tc.x, p); got != tc.expRound {
+				t.Errorf("Got %v, Expected %v", got, tc.expRound)
+			}
+		})
+	}
+}
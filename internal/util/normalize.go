@@ -1,11 +1,19 @@
 package util
 
 import (
+	"math"
+
 	"github.com/rhagenson/relped/internal/unit"
 )
 
 // NormalizeRelatedness normalizes the Relatedness values to be [0,1]-bounded
 // if all values are already between [0,1] NormalizeRelatedness does nothing
+//
+// As 0 and 1 are always part of the range, values are only rescaled by
+// how far they fall outside of [0,1]:
+//
+//	[0.2, 0.8]  --> [0.2, 0.8]
+//	[-0.3, 1.5] --> [0.0, 1.0], with 0.0 becoming 0.17
 func NormalizeRelatedness(rels map[string]map[string]unit.Relatedness) map[string]map[string]unit.Relatedness {
 	var min, max = 0.0, 1.0
 	var relVal float64
@@ -35,3 +43,33 @@ func NormalizeRelatedness(rels map[string]map[string]unit.Relatedness) map[strin
 	}
 	return cp
 }
+
+// NormalizeRelatednessToData normalizes the Relatedness values so the
+// smallest observed value becomes 0 and the largest becomes 1
+// if all values are equal NormalizeRelatednessToData does nothing
+//
+// Unlike NormalizeRelatedness, values are rescaled to the observed range alone:
+//
+//	[0.2, 0.8]  --> [0.0, 1.0], with 0.5 becoming 0.5
+//	[-0.3, 1.5] --> [0.0, 1.0], with 0.0 becoming 0.17
+func NormalizeRelatednessToData(rels map[string]map[string]unit.Relatedness) map[string]map[string]unit.Relatedness {
+	var min, max = math.Inf(1), math.Inf(-1)
+	for _, m := range rels {
+		for _, rel := range m {
+			min = math.Min(min, float64(rel))
+			max = math.Max(max, float64(rel))
+		}
+	}
+	if !(min < max) {
+		return rels
+	}
+
+	cp := make(map[string]map[string]unit.Relatedness, len(rels))
+	for from, m := range rels {
+		cp[from] = make(map[string]unit.Relatedness, len(m))
+		for to, rel := range m {
+			cp[from][to] = unit.Relatedness((float64(rel) - min) / (max - min))
+		}
+	}
+	return cp
+}
@@ -93,3 +93,65 @@ func TestNormalizeRelatedness(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeRelatednessToData(t *testing.T) {
+	tt := []struct {
+		name string
+		rels map[string]map[string]unit.Relatedness
+		exp  map[string]map[string]unit.Relatedness
+	}{
+		{
+			name: "Values in range are stretched to [0,1]",
+			rels: map[string]map[string]unit.Relatedness{
+				"I1": map[string]unit.Relatedness{
+					"I2": unit.Relatedness(0.25),
+					"I3": unit.Relatedness(0.5),
+				},
+				"I2": map[string]unit.Relatedness{
+					"I3": unit.Relatedness(0.75),
+				},
+			},
+			exp: map[string]map[string]unit.Relatedness{
+				"I1": map[string]unit.Relatedness{
+					"I2": unit.Relatedness(0),
+					"I3": unit.Relatedness(0.5),
+				},
+				"I2": map[string]unit.Relatedness{
+					"I3": unit.Relatedness(1),
+				},
+			},
+		},
+		{
+			name: "Equal values do not change",
+			rels: map[string]map[string]unit.Relatedness{
+				"I1": map[string]unit.Relatedness{
+					"I2": unit.Relatedness(0.25),
+				},
+				"I2": map[string]unit.Relatedness{
+					"I3": unit.Relatedness(0.25),
+				},
+			},
+			exp: map[string]map[string]unit.Relatedness{
+				"I1": map[string]unit.Relatedness{
+					"I2": unit.Relatedness(0.25),
+				},
+				"I2": map[string]unit.Relatedness{
+					"I3": unit.Relatedness(0.25),
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := util.NormalizeRelatednessToData(tc.rels)
+			for from, m := range got {
+				for to := range m {
+					if got[from][to] != tc.exp[from][to] {
+						t.Errorf("Got %v, Expected %v", got, tc.exp)
+					}
+				}
+			}
+		})
+	}
+}
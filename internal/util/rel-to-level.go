@@ -1,25 +1,74 @@
 package util
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/rhagenson/relped/internal/unit/relational"
 )
 
+// Rounding is how RelToLevel rounds the generations implied by
+// relatedness falling between two relational distances
+type Rounding uint
+
+const (
+	// RoundNearest takes the nearest distance, halfway rounding further
+	RoundNearest Rounding = iota
+	// RoundFloor takes the closer distance, a liberal assignment
+	RoundFloor
+	// RoundCeil takes the further distance, a conservative assignment
+	RoundCeil
+)
+
+// ParseRounding converts the name of a rounding mode to its Rounding
+func ParseRounding(name string) (Rounding, error) {
+	switch name {
+	case "nearest":
+		return RoundNearest, nil
+	case "floor":
+		return RoundFloor, nil
+	case "ceil":
+		return RoundCeil, nil
+	default:
+		return RoundNearest, fmt.Errorf("unknown rounding %q, use one of: nearest, floor, ceil", name)
+	}
+}
+
+// boundaryTolerance is how near relatedness must be to an exact power of
+// one half to take its distance, regardless of rounding, so input such
+// as 0.2500000001 is not tipped to another distance by floating point
+const boundaryTolerance = 1e-6
+
 // RelToLevel computes the relational distance given the relatedness score
 //
 // Examples:
-//     relToLevel(0.5)   --> First
-//     relToLevel(0.25)  --> Second
-//     relToLevel(0.125) --> Third
-//	   ...
-//     relToLevel(<=0)   --> Unrelated
-func RelToLevel(x float64) relational.Degree {
+//
+//	relToLevel(0.5)   --> First
+//	relToLevel(0.25)  --> Second
+//	relToLevel(0.125) --> Third
+//	...
+//	relToLevel(>=1)   --> First
+//	relToLevel(<=0)   --> Unrelated
+//
+// Relatedness between two distances is rounded by mode
+func RelToLevel(x float64, mode Rounding) relational.Degree {
 	if x <= 0 {
 		return relational.Unrelated
 	}
-	switch uint(math.Round(math.Log(1/x) / math.Log(2))) {
-	case 1:
+	if 1 <= x { // Closer than parent-offspring is still a direct link
+		return relational.First
+	}
+	gens := math.Log2(1 / x)
+	switch nearest := math.Round(gens); {
+	case math.Abs(gens-nearest) < boundaryTolerance, mode == RoundNearest:
+		gens = nearest
+	case mode == RoundFloor:
+		gens = math.Floor(gens)
+	case mode == RoundCeil:
+		gens = math.Ceil(gens)
+	}
+	switch uint(gens) {
+	case 0, 1:
 		return relational.First
 	case 2:
 		return relational.Second
@@ -0,0 +1,67 @@
+package util_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rhagenson/relped/internal/unit/relational"
+	"github.com/rhagenson/relped/internal/util"
+)
+
+func TestRelToLevel(t *testing.T) {
+	tt := []struct {
+		name string
+		rel  float64
+		exp  relational.Degree
+	}{
+		{name: "One half is first", rel: 0.5, exp: relational.First},
+		{name: "One quarter is second", rel: 0.25, exp: relational.Second},
+		{name: "One eighth is third", rel: 0.125, exp: relational.Third},
+		{name: "Powers of two map to their exponent", rel: math.Pow(0.5, 9), exp: relational.Ninth},
+		{name: "Rounds down to first", rel: 0.36, exp: relational.First},
+		{name: "Rounds up to second", rel: 0.35, exp: relational.Second},
+		{name: "Just below tenth is ninth", rel: 0.0015, exp: relational.Ninth},
+		{name: "Tenth and beyond is unrelated", rel: 0.001, exp: relational.Unrelated},
+		{name: "Very small positive is unrelated", rel: 1e-12, exp: relational.Unrelated},
+		{name: "Zero is unrelated", rel: 0, exp: relational.Unrelated},
+		{name: "Negative is unrelated", rel: -0.5, exp: relational.Unrelated},
+		// Values rounding to a distance of zero are direct links
+		{name: "Near one is first", rel: 0.75, exp: relational.First},
+		{name: "One is first", rel: 1, exp: relational.First},
+		{name: "Over one is first", rel: 3, exp: relational.First},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := util.RelToLevel(tc.rel, util.RoundNearest); got != tc.exp {
+				t.Errorf("Got %v, Expected %v", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestRelToLevelRounding(t *testing.T) {
+	halfway := math.Pow(0.5, 1.5) // Between first and second
+	tt := []struct {
+		name     string
+		rounding util.Rounding
+		rel      float64
+		exp      relational.Degree
+	}{
+		{name: "Nearest rounds halfway further", rounding: util.RoundNearest, rel: halfway, exp: relational.Second},
+		{name: "Floor rounds halfway closer", rounding: util.RoundFloor, rel: halfway, exp: relational.First},
+		{name: "Ceil rounds halfway further", rounding: util.RoundCeil, rel: halfway, exp: relational.Second},
+		{name: "Floor rounds near second closer", rounding: util.RoundFloor, rel: 0.26, exp: relational.First},
+		{name: "Ceil rounds near first further", rounding: util.RoundCeil, rel: 0.49, exp: relational.Second},
+		{name: "Floor keeps a boundary", rounding: util.RoundFloor, rel: 0.2500000001, exp: relational.Second},
+		{name: "Ceil keeps a boundary", rounding: util.RoundCeil, rel: 0.2499999999, exp: relational.Second},
+		{name: "Ceil past ninth is unrelated", rounding: util.RoundCeil, rel: 0.0015, exp: relational.Unrelated},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := util.RelToLevel(tc.rel, tc.rounding); got != tc.exp {
+				t.Errorf("Got %v, Expected %v", got, tc.exp)
+			}
+		})
+	}
+}
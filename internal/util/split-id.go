@@ -0,0 +1,15 @@
+package util
+
+import "strings"
+
+// SplitID splits a composite ID, such as FAM1_IND3, into its family and
+// individual parts at the first delim. IDs without delim have no family.
+func SplitID(id, delim string) (family, indv string) {
+	if delim == "" {
+		return "", id
+	}
+	if parts := strings.SplitN(id, delim, 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return "", id
+}
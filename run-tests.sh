@@ -86,4 +86,111 @@ relped build \
     --unmapped=/tmp/relped-unmapped.txt \
 && [[ -f /tmp/relped-unmapped.txt && -s /tmp/relped-unmapped.txt ]]  # Checks that file exists (-f) and has a size (-s)
 
+# --output-individuals and --output-dropped account for every individual
+relped build \
+    --relatedness=$relatedness \
+    --output=/dev/null \
+    --output-individuals=/tmp/relped-individuals.txt \
+    --output-dropped=/tmp/relped-dropped.csv \
+&& [[ -s /tmp/relped-individuals.txt ]] \
+&& head -n 1 /tmp/relped-dropped.csv | grep -q '^ID,Reason$'
+
+# Output to a missing directory fails unless --mkdir is added
+rm -rf /tmp/relped-missing
+! relped build \
+    --relatedness=$relatedness \
+    --output=/tmp/relped-missing/out.dot \
+&& relped build \
+    --relatedness=$relatedness \
+    --output=/tmp/relped-missing/out.dot \
+    --mkdir \
+&& [[ -s /tmp/relped-missing/out.dot ]] \
+&& rm -r /tmp/relped-missing
+
+# --simple-layout drops orthogonal splines
+relped build \
+    --relatedness=$relatedness \
+    --output=/tmp/relped-out.txt \
+    --simple-layout \
+&& ! grep -q "splines=ortho" /tmp/relped-out.txt
+
+# --ml-relate reads ML-Relate output
+relped build \
+    --relatedness=example-data/ml-relate.csv \
+    --ml-relate \
+    --output=/dev/null
+
+# convert writes ML-Relate output as three-column relatedness
+relped convert \
+    --ml-relate=example-data/ml-relate.csv \
+    --output=/tmp/relped-converted.csv \
+&& head -n 1 /tmp/relped-converted.csv | grep -q "ID1,ID2,Rel"
+
+# --output-summary writes a JSON report of the run
+relped build \
+    --relatedness=$relatedness \
+    --output=/dev/null \
+    --output-summary=/tmp/relped-summary.json \
+&& grep -q '"pairs_kept"' /tmp/relped-summary.json \
+&& grep -q '"histogram"' /tmp/relped-summary.json
+
+# diff finds no differences between a pedigree and itself
+relped build \
+    --relatedness=$relatedness \
+    --output=/tmp/relped-out.txt \
+&& relped diff \
+    --from=/tmp/relped-out.txt \
+    --to=/tmp/relped-out.txt \
+| grep -q "0 related pairs added, 0 removed, 0 at a different distance"
+
+# Exit codes tell apart failures: 1 usage, 2 IO, 4 parse, 5 validation
+code=0
+relped build \
+    --relatedness=$relatedness \
+    --output=/dev/null \
+    --format=svg 2>/dev/null || code=$?
+[[ $code -eq 1 ]]
+
+code=0
+relped build \
+    --relatedness=/tmp/relped-does-not-exist.csv \
+    --output=/dev/null 2>/dev/null || code=$?
+[[ $code -eq 2 ]]
+
+# Output that cannot be written is an IO error, not a silent success
+code=0
+relped build \
+    --relatedness=$relatedness \
+    --output=/dev/full 2>/dev/null || code=$?
+[[ $code -eq 2 ]]
+
+code=0
+relped build \
+    --relatedness=<( printf "ID1,ID2,Rel\nA,B,PO\nA,C,distant\n" ) \
+    --output=/dev/null \
+    --input-categorical 2>/dev/null || code=$?
+[[ $code -eq 4 ]]
+
+# Empty relatedness input is a parse error in either format, not a crash
+: > /tmp/relped-empty.csv
+code=0
+relped build \
+    --relatedness=/tmp/relped-empty.csv \
+    --output=/dev/null 2>/dev/null || code=$?
+[[ $code -eq 4 ]]
+
+code=0
+relped build \
+    --relatedness=/tmp/relped-empty.csv \
+    --output=/dev/null \
+    --ml-relate 2>/dev/null || code=$?
+[[ $code -eq 4 ]]
+
+code=0
+relped build \
+    --relatedness=<( printf "ID1,ID2,Rel\nA,B,0.5\nA,C,NaN\n" ) \
+    --output=/dev/null \
+    --strict 2>/dev/null || code=$?
+[[ $code -eq 5 ]]
+
 exit "$result"